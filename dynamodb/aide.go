@@ -8,7 +8,9 @@ package dynamodb
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
@@ -17,7 +19,8 @@ import (
 
 // Service provides access to data in DynamoDB.
 type Service struct {
-	svc dynamodbiface.DynamoDBAPI
+	svc       dynamodbiface.DynamoDBAPI
+	cursorKey []byte
 }
 
 // New returns an initialized DB aide.
@@ -33,17 +36,67 @@ func (svc *Service) GetItem(in *dynamodb.GetItemInput, out interface{}) error {
 }
 
 // GetItemWithContext and unmarshal response items into given interface{}.
+// ErrNotFound is returned if there is no item with the key.
 func (svc *Service) GetItemWithContext(ctx context.Context, in *dynamodb.GetItemInput, out interface{}) error {
 	resp, err := svc.svc.GetItemWithContext(ctx, in)
 	if err != nil {
-		return err
+		return wrapError("GetItem", err)
 	}
 	if resp.Item == nil {
-		return fmt.Errorf("GetItem failed")
+		return ErrNotFound
 	}
 	return dynamodbattribute.UnmarshalMap(resp.Item, out)
 }
 
+// GetOptions are the settings of a Get.
+type GetOptions struct {
+	// ConsistentRead reads the item with strong consistency.
+	ConsistentRead bool
+
+	// Projection names the top-level attributes to get; all are got when empty.
+	Projection []string
+}
+
+// Get gets the item with the key, a struct or map of the key attributes, from
+// the table and unmarshals it into out.
+// See GetWithContext.
+func (svc *Service) Get(table string, key, out interface{}, opts *GetOptions) error {
+	return svc.GetWithContext(context.TODO(), table, key, out, opts)
+}
+
+// GetWithContext gets the item with the key, a struct or map of the key
+// attributes, from the table and unmarshals it into out. opts may be nil.
+// ErrNotFound is returned if there is no item with the key.
+func (svc *Service) GetWithContext(ctx context.Context, table string, key, out interface{}, opts *GetOptions) error {
+	k, err := dynamodbattribute.MarshalMap(key)
+	if err != nil {
+		return err
+	}
+	in := &dynamodb.GetItemInput{Key: k, TableName: aws.String(table)}
+	if opts != nil {
+		if opts.ConsistentRead {
+			in.ConsistentRead = aws.Bool(true)
+		}
+		in.ProjectionExpression, in.ExpressionAttributeNames = projection(opts.Projection)
+	}
+	return svc.GetItemWithContext(ctx, in, out)
+}
+
+// projection returns the expression and attribute names projecting the
+// top-level attributes, or nils if there are none.
+func projection(attrs []string) (*string, map[string]*string) {
+	if len(attrs) == 0 {
+		return nil, nil
+	}
+	names := make([]string, len(attrs))
+	placeholders := make(map[string]*string, len(attrs))
+	for i, attr := range attrs {
+		names[i] = fmt.Sprintf("#p%d", i)
+		placeholders[names[i]] = aws.String(attr)
+	}
+	return aws.String(strings.Join(names, ", ")), placeholders
+}
+
 // PutItem by marshalling the given interface{} into the given PutItemInput.
 func (svc *Service) PutItem(in *dynamodb.PutItemInput, item interface{}) (out *dynamodb.PutItemOutput, err error) {
 	return svc.PutItemWithContext(context.TODO(), in, item)
@@ -54,7 +107,53 @@ func (svc *Service) PutItemWithContext(ctx context.Context, in *dynamodb.PutItem
 	if in.Item, err = dynamodbattribute.MarshalMap(item); err != nil {
 		return nil, err
 	}
-	return svc.svc.PutItemWithContext(ctx, in)
+	out, err = svc.svc.PutItemWithContext(ctx, in)
+	return out, wrapError("PutItem", err)
+}
+
+// UpdateItem and unmarshal the returned attributes into given interface{}.
+// See UpdateItemWithContext.
+func (svc *Service) UpdateItem(in *dynamodb.UpdateItemInput, out interface{}) error {
+	return svc.UpdateItemWithContext(context.TODO(), in, out)
+}
+
+// UpdateItemWithContext updates the item and unmarshals the attributes chosen
+// by in.ReturnValues, e.g. ALL_NEW, into out, which may be nil. The input is
+// usually built with NewUpdateItemInput, from an update expression or, with
+// SetAll, a partial struct. An update whose condition does not hold fails
+// with a ConditionFailedError.
+func (svc *Service) UpdateItemWithContext(ctx context.Context, in *dynamodb.UpdateItemInput, out interface{}) error {
+	resp, err := svc.svc.UpdateItemWithContext(ctx, in)
+	if err != nil {
+		return wrapError("UpdateItem", err)
+	}
+	return unmarshalAttributes(resp.Attributes, out)
+}
+
+// DeleteItem and unmarshal the returned attributes into given interface{}.
+// See DeleteItemWithContext.
+func (svc *Service) DeleteItem(in *dynamodb.DeleteItemInput, out interface{}) error {
+	return svc.DeleteItemWithContext(context.TODO(), in, out)
+}
+
+// DeleteItemWithContext deletes the item and, when in.ReturnValues is
+// ALL_OLD, unmarshals the deleted item into out, which may be nil. The input
+// is usually built with NewDeleteItemInput. A delete whose condition does not
+// hold fails with a ConditionFailedError.
+func (svc *Service) DeleteItemWithContext(ctx context.Context, in *dynamodb.DeleteItemInput, out interface{}) error {
+	resp, err := svc.svc.DeleteItemWithContext(ctx, in)
+	if err != nil {
+		return wrapError("DeleteItem", err)
+	}
+	return unmarshalAttributes(resp.Attributes, out)
+}
+
+// unmarshalAttributes unmarshals returned attributes into out, if both are given.
+func unmarshalAttributes(attrs map[string]*dynamodb.AttributeValue, out interface{}) error {
+	if out == nil || len(attrs) == 0 {
+		return nil
+	}
+	return dynamodbattribute.UnmarshalMap(attrs, out)
 }
 
 // Query the table and unmarshal all results.
@@ -70,7 +169,7 @@ func (svc *Service) QueryWithContext(ctx context.Context, in *dynamodb.QueryInpu
 		return !last
 	}
 	if err := svc.svc.QueryPagesWithContext(ctx, in, pager); err != nil {
-		return err
+		return wrapError("Query", err)
 	}
 	return dynamodbattribute.UnmarshalListOfMaps(items, out)
 }
@@ -87,6 +186,9 @@ func (svc *Service) QueryPages(in *dynamodb.QueryInput, outItem interface{}, out
 // Provided pager function should take a single interface{} and assert the type of the item (e.g. Item),
 // and a boolean which will indicate whether this is the last item.
 // Provided pager function should return false if it wants to stop processing.
+// The item is zeroed before each is unmarshalled into it, and the boolean is
+// true only for the final item of the query. See QueryStream for a new
+// value per item and per page callbacks.
 // Example:
 // items := Item
 // pager := func(out interface{}, last bool) bool {
@@ -97,23 +199,7 @@ func (svc *Service) QueryPages(in *dynamodb.QueryInput, outItem interface{}, out
 //	return err
 // }
 func (svc *Service) QueryPagesWithContext(ctx context.Context, in *dynamodb.QueryInput, outItem interface{}, outPager func(interface{}, bool) bool) error {
-	var marshallErr error
-	pager := func(pageOut *dynamodb.QueryOutput, last bool) bool {
-		for _, item := range pageOut.Items {
-			marshallErr = dynamodbattribute.UnmarshalMap(item, outItem)
-			if marshallErr != nil {
-				return false
-			}
-			if !outPager(outItem, last) {
-				return false
-			}
-		}
-		return !last
-	}
-	if err := svc.svc.QueryPagesWithContext(ctx, in, pager); err != nil {
-		return err
-	}
-	return marshallErr
+	return svc.QueryStreamWithContext(ctx, in, Pager{New: reuse(outItem), Item: outPager})
 }
 
 // Scan the table and return all results.
@@ -129,7 +215,7 @@ func (svc *Service) ScanWithContext(ctx context.Context, in *dynamodb.ScanInput,
 		return !last
 	}
 	if err := svc.svc.ScanPagesWithContext(ctx, in, pager); err != nil {
-		return err
+		return wrapError("Scan", err)
 	}
 	return dynamodbattribute.UnmarshalListOfMaps(items, out)
 }
@@ -146,6 +232,9 @@ func (svc *Service) ScanPages(in *dynamodb.ScanInput, outItem interface{}, outPa
 // Provided pager function should take a single interface{} and assert the type of the item (e.g. Item),
 // and a boolean which will indicate whether this is the last item.
 // Provided pager function should return false if it wants to stop processing.
+// The item is zeroed before each is unmarshalled into it, and the boolean is
+// true only for the final item of the scan. See ScanStream for a new
+// value per item and per page callbacks.
 // Example:
 // items := Item
 // pager := func(out interface{}, last bool) bool {
@@ -156,21 +245,5 @@ func (svc *Service) ScanPages(in *dynamodb.ScanInput, outItem interface{}, outPa
 //	return err
 // }
 func (svc *Service) ScanPagesWithContext(ctx context.Context, in *dynamodb.ScanInput, outItem interface{}, outPager func(interface{}, bool) bool) error {
-	var marshallErr error
-	pager := func(pageOut *dynamodb.ScanOutput, last bool) bool {
-		for _, item := range pageOut.Items {
-			marshallErr = dynamodbattribute.UnmarshalMap(item, outItem)
-			if marshallErr != nil {
-				return false
-			}
-			if !outPager(outItem, last) {
-				return false
-			}
-		}
-		return !last
-	}
-	if err := svc.svc.ScanPagesWithContext(ctx, in, pager); err != nil {
-		return err
-	}
-	return marshallErr
+	return svc.ScanStreamWithContext(ctx, in, Pager{New: reuse(outItem), Item: outPager})
 }
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/cleardataeng/aidews/dynamodb/extmocks/github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/golang/mock/gomock"
@@ -40,7 +41,7 @@ func TestService_QueryPagesWithContext(t *testing.T) {
 	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
 
 	ddbMock.EXPECT().QueryPagesWithContext(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
-		func(_ context.Context, input *dynamodb.QueryInput, f func(*dynamodb.QueryOutput, bool) bool) error {
+		func(_ context.Context, input *dynamodb.QueryInput, f func(*dynamodb.QueryOutput, bool) bool, _ ...request.Option) error {
 			if *input.TableName != pagesTable {
 				t.Errorf(`table: want: "%s", got: "%s"`, pagesTable, *input.TableName)
 			}
@@ -85,7 +86,7 @@ func TestService_ScanPagesWithContext(t *testing.T) {
 	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
 
 	ddbMock.EXPECT().ScanPagesWithContext(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
-		func(_ context.Context, input *dynamodb.ScanInput, f func(*dynamodb.ScanOutput, bool) bool) error {
+		func(_ context.Context, input *dynamodb.ScanInput, f func(*dynamodb.ScanOutput, bool) bool, _ ...request.Option) error {
 			if *input.TableName != pagesTable {
 				t.Errorf(`table: want: "%s", got: "%s"`, pagesTable, *input.TableName)
 			}
@@ -0,0 +1,265 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// The most items allowed by AWS in a BatchGetItem and a BatchWriteItem call.
+// https://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_BatchGetItem.html
+// https://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_BatchWriteItem.html
+const (
+	maxBatchGetItems   = 100
+	maxBatchWriteItems = 25
+)
+
+// Defaults of BatchOptions.
+const (
+	defaultBatchConcurrency = 4
+	defaultBatchRetries     = 8
+	defaultBatchDelay       = 50 * time.Millisecond
+	maxBatchDelay           = 5 * time.Second
+)
+
+// BatchOptions configures BatchGet and BatchWrite. The zero value runs four
+// chunks at once and retries unprocessed items eight times.
+type BatchOptions struct {
+	// Concurrency is the number of chunks sent at once; 4 when zero.
+	Concurrency int
+
+	// ConsistentRead reads the items of a BatchGet with strong consistency.
+	ConsistentRead bool
+
+	// Retries is the number of times unprocessed items of a chunk are sent
+	// again, with exponential backoff from BaseDelay; 8 and 50ms when zero.
+	Retries   int
+	BaseDelay time.Duration
+}
+
+func (opts *BatchOptions) concurrency() int {
+	if opts == nil || opts.Concurrency <= 0 {
+		return defaultBatchConcurrency
+	}
+	return opts.Concurrency
+}
+
+// backoff waits before the retry of the given attempt, from zero, returning
+// false if retries are exhausted or ctx is done.
+func (opts *BatchOptions) backoff(ctx context.Context, attempt int) bool {
+	retries, delay := defaultBatchRetries, defaultBatchDelay
+	if opts != nil && opts.Retries > 0 {
+		retries = opts.Retries
+	}
+	if opts != nil && opts.BaseDelay > 0 {
+		delay = opts.BaseDelay
+	}
+	if attempt >= retries {
+		return false
+	}
+	delay <<= uint(attempt)
+	if delay <= 0 || delay > maxBatchDelay {
+		delay = maxBatchDelay
+	}
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1)) // jitter
+	select {
+	case <-time.After(delay):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// BatchGet gets the items with the keys, a slice of structs or maps of the key
+// attributes, from the table and appends them to out, which must point to a
+// slice.
+// See BatchGetWithContext.
+func (svc *Service) BatchGet(table string, keys interface{}, out interface{}, opts *BatchOptions) error {
+	return svc.BatchGetWithContext(context.TODO(), table, keys, out, opts)
+}
+
+// BatchGetWithContext gets the items with the keys, a slice of structs or maps
+// of the key attributes, from the table and appends them to out, which must
+// point to a slice. Keys are read in chunks of 100, several at once, so items
+// are appended in no particular order, and keys without an item are skipped.
+// The keys must be unique. opts may be nil.
+func (svc *Service) BatchGetWithContext(ctx context.Context, table string, keys interface{}, out interface{}, opts *BatchOptions) error {
+	outVal := reflect.ValueOf(out)
+	if outVal.Kind() != reflect.Ptr || outVal.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("BatchGet: out must be a pointer to a slice, got %T", out)
+	}
+	ks, err := marshalSlice(keys)
+	if err != nil {
+		return err
+	}
+	var mu sync.Mutex
+	var items []map[string]*dynamodb.AttributeValue
+	err = runChunks(ctx, len(ks), maxBatchGetItems, opts.concurrency(), func(ctx context.Context, from, to int) error {
+		got, err := svc.batchGetChunk(ctx, table, ks[from:to], opts)
+		mu.Lock()
+		items = append(items, got...)
+		mu.Unlock()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	page := reflect.New(outVal.Elem().Type())
+	if err := dynamodbattribute.UnmarshalListOfMaps(items, page.Interface()); err != nil {
+		return err
+	}
+	outVal.Elem().Set(reflect.AppendSlice(outVal.Elem(), page.Elem()))
+	return nil
+}
+
+func (svc *Service) batchGetChunk(ctx context.Context, table string, keys []map[string]*dynamodb.AttributeValue, opts *BatchOptions) ([]map[string]*dynamodb.AttributeValue, error) {
+	ka := &dynamodb.KeysAndAttributes{Keys: keys}
+	if opts != nil && opts.ConsistentRead {
+		ka.ConsistentRead = aws.Bool(true)
+	}
+	in := &dynamodb.BatchGetItemInput{RequestItems: map[string]*dynamodb.KeysAndAttributes{table: ka}}
+	var items []map[string]*dynamodb.AttributeValue
+	for attempt := 0; ; attempt++ {
+		res, err := svc.svc.BatchGetItemWithContext(ctx, in)
+		if err != nil {
+			return items, wrapError("BatchGetItem", err)
+		}
+		items = append(items, res.Responses[table]...)
+		left := res.UnprocessedKeys[table]
+		if left == nil || len(left.Keys) == 0 {
+			return items, nil
+		}
+		if !opts.backoff(ctx, attempt) {
+			if err := ctx.Err(); err != nil {
+				return items, err
+			}
+			return items, &ThrottledError{Op: "BatchGetItem", Err: fmt.Errorf("%d keys unprocessed after %d retries", len(left.Keys), attempt)}
+		}
+		in.RequestItems = map[string]*dynamodb.KeysAndAttributes{table: left}
+	}
+}
+
+// BatchWrite puts the items and deletes the items with the keys in the table.
+// See BatchWriteWithContext.
+func (svc *Service) BatchWrite(table string, puts, deletes interface{}, opts *BatchOptions) error {
+	return svc.BatchWriteWithContext(context.TODO(), table, puts, deletes, opts)
+}
+
+// BatchWriteWithContext puts the items and deletes the items with the keys in
+// the table. puts is a slice of items and deletes a slice of structs or maps
+// of the key attributes; either may be nil. Writes are sent in chunks of 25,
+// several at once, so they are applied in no particular order, and an item may
+// not be both put and deleted. opts may be nil.
+func (svc *Service) BatchWriteWithContext(ctx context.Context, table string, puts, deletes interface{}, opts *BatchOptions) error {
+	var reqs []*dynamodb.WriteRequest
+	if puts != nil {
+		items, err := marshalSlice(puts)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			reqs = append(reqs, &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: item}})
+		}
+	}
+	if deletes != nil {
+		keys, err := marshalSlice(deletes)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			reqs = append(reqs, &dynamodb.WriteRequest{DeleteRequest: &dynamodb.DeleteRequest{Key: key}})
+		}
+	}
+	return runChunks(ctx, len(reqs), maxBatchWriteItems, opts.concurrency(), func(ctx context.Context, from, to int) error {
+		return svc.batchWriteChunk(ctx, table, reqs[from:to], opts)
+	})
+}
+
+func (svc *Service) batchWriteChunk(ctx context.Context, table string, reqs []*dynamodb.WriteRequest, opts *BatchOptions) error {
+	in := &dynamodb.BatchWriteItemInput{RequestItems: map[string][]*dynamodb.WriteRequest{table: reqs}}
+	for attempt := 0; ; attempt++ {
+		res, err := svc.svc.BatchWriteItemWithContext(ctx, in)
+		if err != nil {
+			return wrapError("BatchWriteItem", err)
+		}
+		left := res.UnprocessedItems[table]
+		if len(left) == 0 {
+			return nil
+		}
+		if !opts.backoff(ctx, attempt) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return &ThrottledError{Op: "BatchWriteItem", Err: fmt.Errorf("%d items unprocessed after %d retries", len(left), attempt)}
+		}
+		in.RequestItems = map[string][]*dynamodb.WriteRequest{table: left}
+	}
+}
+
+// runChunks calls fn for each chunk of n items, size at a time, running
+// concurrency calls at once and stopping at the first error.
+func runChunks(ctx context.Context, n, size, concurrency int, fn func(ctx context.Context, from, to int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	work := make(chan int)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for from := range work {
+				to := from + size
+				if to > n {
+					to = n
+				}
+				if err := fn(ctx, from, to); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+feed:
+	for from := 0; from < n; from += size {
+		select {
+		case work <- from:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// marshalSlice marshals each element of the slice v into an attribute map.
+func marshalSlice(v interface{}) ([]map[string]*dynamodb.AttributeValue, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("expected a slice, got %T", v)
+	}
+	out := make([]map[string]*dynamodb.AttributeValue, rv.Len())
+	for i := range out {
+		m, err := dynamodbattribute.MarshalMap(rv.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		out[i] = m
+	}
+	return out, nil
+}
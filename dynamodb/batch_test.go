@@ -0,0 +1,125 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cleardataeng/aidews/dynamodb/extmocks/github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/golang/mock/gomock"
+)
+
+func TestService_BatchGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	var mu sync.Mutex
+	retried := false
+	ddbMock.EXPECT().BatchGetItemWithContext(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(_ context.Context, in *dynamodb.BatchGetItemInput, _ ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+			keys := in.RequestItems[pagesTable].Keys
+			if len(keys) > maxBatchGetItems {
+				t.Errorf(`keys: want at most %d, got: %d`, maxBatchGetItems, len(keys))
+			}
+			res := &dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]*dynamodb.AttributeValue{}}
+			for _, k := range keys {
+				slug := aws.StringValue(k["slug"].S)
+				mu.Lock()
+				// the first time it is asked for, one key is left unprocessed
+				if slug == "key-150" && !retried {
+					retried = true
+					mu.Unlock()
+					res.UnprocessedKeys = map[string]*dynamodb.KeysAndAttributes{pagesTable: {Keys: []map[string]*dynamodb.AttributeValue{k}}}
+					continue
+				}
+				mu.Unlock()
+				res.Responses[pagesTable] = append(res.Responses[pagesTable], map[string]*dynamodb.AttributeValue{
+					"slug": {S: aws.String(slug)}, "title": {S: aws.String("title of " + slug)},
+				})
+			}
+			return res, nil
+		},
+	)
+
+	keys := make([]map[string]string, 250)
+	for i := range keys {
+		keys[i] = map[string]string{"slug": fmt.Sprintf("key-%03d", i)}
+	}
+	svc := Service{svc: ddbMock}
+	var got []row
+	if err := svc.BatchGetWithContext(ctx, pagesTable, keys, &got, &BatchOptions{BaseDelay: time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(keys) {
+		t.Fatalf(`items: want: %d, got: %d`, len(keys), len(got))
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].Slug < got[j].Slug })
+	if got[150].Title != "title of key-150" {
+		t.Errorf(`item 150: want: "title of key-150", got: "%s"`, got[150].Title)
+	}
+	if !retried {
+		t.Error("unprocessed key was not retried")
+	}
+}
+
+func TestService_BatchWrite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	var mu sync.Mutex
+	puts, deletes := 0, 0
+	ddbMock.EXPECT().BatchWriteItemWithContext(gomock.Any(), gomock.Any()).Times(2).DoAndReturn(
+		func(_ context.Context, in *dynamodb.BatchWriteItemInput, _ ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+			reqs := in.RequestItems[pagesTable]
+			if len(reqs) > maxBatchWriteItems {
+				t.Errorf(`requests: want at most %d, got: %d`, maxBatchWriteItems, len(reqs))
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, r := range reqs {
+				if r.PutRequest != nil {
+					puts++
+				} else {
+					deletes++
+				}
+			}
+			return &dynamodb.BatchWriteItemOutput{}, nil
+		},
+	)
+
+	items := make([]row, 40)
+	for i := range items {
+		items[i] = row{Slug: fmt.Sprintf("put-%d", i)}
+	}
+	keys := []map[string]string{{"slug": "gone-1"}, {"slug": "gone-2"}}
+	svc := Service{svc: ddbMock}
+	if err := svc.BatchWriteWithContext(ctx, pagesTable, items, keys, nil); err != nil {
+		t.Fatal(err)
+	}
+	if puts != 40 || deletes != 2 {
+		t.Errorf(`writes: want: 40 puts and 2 deletes, got: %d and %d`, puts, deletes)
+	}
+}
+
+func TestService_BatchWriteUnprocessed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	ddbMock.EXPECT().BatchWriteItemWithContext(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(
+		func(_ context.Context, in *dynamodb.BatchWriteItemInput, _ ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+			return &dynamodb.BatchWriteItemOutput{UnprocessedItems: in.RequestItems}, nil
+		},
+	)
+
+	svc := Service{svc: ddbMock}
+	err := svc.BatchWriteWithContext(ctx, pagesTable, []row{{Slug: "xkcd"}}, nil, &BatchOptions{Retries: 2, BaseDelay: time.Millisecond})
+	if _, ok := err.(*ThrottledError); !ok {
+		t.Errorf(`err: want: ThrottledError, got: %v`, err)
+	}
+}
@@ -0,0 +1,191 @@
+package dynamodb
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// ErrInvalidCursor is returned by QueryPage and ScanPage for a cursor that is
+// malformed, badly signed or from another query or scan.
+var ErrInvalidCursor = errors.New("dynamodb: invalid cursor")
+
+// SetCursorKey sets the key with which the cursors of QueryPage and ScanPage
+// are signed with HMAC-SHA256, so clients cannot forge them. Cursors are
+// then only accepted with a valid signature. A nil key disables signing.
+//
+// SetCursorKey must be called before the Service is used, as it is not safe
+// to call while pages are read concurrently.
+func (svc *Service) SetCursorKey(key []byte) {
+	svc.cursorKey = key
+}
+
+// QueryPage reads one page of the query, of up to in.Limit items, from the
+// cursor, and unmarshals its items into out, a pointer to a slice.
+// See QueryPageWithContext.
+func (svc *Service) QueryPage(in *dynamodb.QueryInput, cursor string, out interface{}) (string, error) {
+	return svc.QueryPageWithContext(context.TODO(), in, cursor, out)
+}
+
+// QueryPageWithContext reads one page of the query, of up to in.Limit items,
+// from the cursor, and unmarshals its items into out, a pointer to a slice.
+// The returned cursor resumes the same query after the page, and is empty
+// after the last; an empty cursor starts at the beginning. A page may be empty
+// when a filter matched none of its items, with a cursor to continue.
+//
+// Cursors are opaque and URL-safe, for handing to clients as "next page"
+// tokens. A cursor is bound to the table, index, key condition, filter and
+// expression attributes of its query, and is invalid for any other. See
+// SetCursorKey.
+func (svc *Service) QueryPageWithContext(ctx context.Context, in *dynamodb.QueryInput, cursor string, out interface{}) (string, error) {
+	q := *in
+	scope := newCursorScope(in.TableName, in.IndexName, in.KeyConditionExpression, in.FilterExpression, in.ExpressionAttributeNames, in.ExpressionAttributeValues)
+	start, err := svc.decodeCursor(cursor, scope)
+	if err != nil {
+		return "", err
+	}
+	q.ExclusiveStartKey = start
+	res, err := svc.svc.QueryWithContext(ctx, &q)
+	if err != nil {
+		return "", wrapError("Query", err)
+	}
+	if err := dynamodbattribute.UnmarshalListOfMaps(res.Items, out); err != nil {
+		return "", err
+	}
+	return svc.encodeCursor(res.LastEvaluatedKey, scope)
+}
+
+// ScanPage reads one page of the scan, of up to in.Limit items, from the
+// cursor, and unmarshals its items into out, a pointer to a slice.
+// See ScanPageWithContext.
+func (svc *Service) ScanPage(in *dynamodb.ScanInput, cursor string, out interface{}) (string, error) {
+	return svc.ScanPageWithContext(context.TODO(), in, cursor, out)
+}
+
+// ScanPageWithContext reads one page of the scan, of up to in.Limit items,
+// from the cursor, and unmarshals its items into out, a pointer to a slice.
+// See QueryPageWithContext.
+func (svc *Service) ScanPageWithContext(ctx context.Context, in *dynamodb.ScanInput, cursor string, out interface{}) (string, error) {
+	s := *in
+	scope := newCursorScope(in.TableName, in.IndexName, nil, in.FilterExpression, in.ExpressionAttributeNames, in.ExpressionAttributeValues)
+	start, err := svc.decodeCursor(cursor, scope)
+	if err != nil {
+		return "", err
+	}
+	s.ExclusiveStartKey = start
+	res, err := svc.svc.ScanWithContext(ctx, &s)
+	if err != nil {
+		return "", wrapError("Scan", err)
+	}
+	if err := dynamodbattribute.UnmarshalListOfMaps(res.Items, out); err != nil {
+		return "", err
+	}
+	return svc.encodeCursor(res.LastEvaluatedKey, scope)
+}
+
+// cursor is the content of an encoded cursor: the last key read, and the
+// table and index it was read from and the hash of the expressions of the
+// query or scan.
+type cursor struct {
+	Table string                    `json:"t"`
+	Index string                    `json:"i,omitempty"`
+	Query string                    `json:"q"`
+	Key   map[string]cursorKeyValue `json:"k"`
+}
+
+// cursorScope is the query or scan a cursor is valid for.
+type cursorScope struct {
+	table, index, query string
+}
+
+// newCursorScope returns the scope of a query or scan of the table and
+// index with the expressions, which are hashed so cursors stay short.
+func newCursorScope(table, index, key, filter *string, names map[string]*string, values map[string]*dynamodb.AttributeValue) cursorScope {
+	// maps marshal with sorted keys, so equal expressions hash equally; the
+	// marshalling cannot fail for these types
+	data, _ := json.Marshal(struct {
+		Key    *string                             `json:"k"`
+		Filter *string                             `json:"f"`
+		Names  map[string]*string                  `json:"n"`
+		Values map[string]*dynamodb.AttributeValue `json:"v"`
+	}{key, filter, names, values})
+	sum := sha256.Sum256(data)
+	return cursorScope{
+		table: aws.StringValue(table),
+		index: aws.StringValue(index),
+		query: base64.RawURLEncoding.EncodeToString(sum[:12]),
+	}
+}
+
+// cursorKeyValue is a key attribute, which is a string, number or binary.
+type cursorKeyValue struct {
+	S *string `json:"S,omitempty"`
+	N *string `json:"N,omitempty"`
+	B []byte  `json:"B,omitempty"`
+}
+
+// encodeCursor returns the cursor resuming after the key in the scope, or ""
+// if the key is empty.
+func (svc *Service) encodeCursor(key map[string]*dynamodb.AttributeValue, scope cursorScope) (string, error) {
+	if len(key) == 0 {
+		return "", nil
+	}
+	c := cursor{Table: scope.table, Index: scope.index, Query: scope.query, Key: make(map[string]cursorKeyValue, len(key))}
+	for name, v := range key {
+		c.Key[name] = cursorKeyValue{S: v.S, N: v.N, B: v.B}
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	if svc.cursorKey == nil {
+		return payload, nil
+	}
+	return payload + "." + base64.RawURLEncoding.EncodeToString(svc.sign(payload)), nil
+}
+
+// decodeCursor returns the key of the cursor, or nil if it is empty. A cursor
+// of another scope is invalid.
+func (svc *Service) decodeCursor(s string, scope cursorScope) (map[string]*dynamodb.AttributeValue, error) {
+	if s == "" {
+		return nil, nil
+	}
+	payload, sig, signed := strings.Cut(s, ".")
+	if svc.cursorKey != nil {
+		got, err := base64.RawURLEncoding.DecodeString(sig)
+		if !signed || err != nil || !hmac.Equal(got, svc.sign(payload)) {
+			return nil, ErrInvalidCursor
+		}
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || len(c.Key) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	if c.Table != scope.table || c.Index != scope.index || c.Query != scope.query {
+		return nil, ErrInvalidCursor
+	}
+	key := make(map[string]*dynamodb.AttributeValue, len(c.Key))
+	for name, v := range c.Key {
+		key[name] = &dynamodb.AttributeValue{S: v.S, N: v.N, B: v.B}
+	}
+	return key, nil
+}
+
+func (svc *Service) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, svc.cursorKey)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
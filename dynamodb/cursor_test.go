@@ -0,0 +1,153 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cleardataeng/aidews/dynamodb/extmocks/github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/golang/mock/gomock"
+)
+
+func TestService_QueryPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	last := map[string]*dynamodb.AttributeValue{"Slug": {S: aws.String("xkcd")}, "Added": {N: aws.String("42")}}
+	gomock.InOrder(
+		ddbMock.EXPECT().QueryWithContext(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, in *dynamodb.QueryInput, _ ...request.Option) (*dynamodb.QueryOutput, error) {
+				if in.ExclusiveStartKey != nil {
+					t.Errorf(`start key: want: nil, got: %v`, in.ExclusiveStartKey)
+				}
+				return &dynamodb.QueryOutput{Items: pagesOutput1, LastEvaluatedKey: last}, nil
+			},
+		),
+		ddbMock.EXPECT().QueryWithContext(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, in *dynamodb.QueryInput, _ ...request.Option) (*dynamodb.QueryOutput, error) {
+				if got := aws.StringValue(in.ExclusiveStartKey["Added"].N); got != "42" {
+					t.Errorf(`start key: want: 42, got: "%s"`, got)
+				}
+				return &dynamodb.QueryOutput{Items: pagesOutput2}, nil
+			},
+		),
+	)
+
+	svc := Service{svc: ddbMock}
+	svc.SetCursorKey([]byte("secret"))
+	in, err := NewQueryInput(pagesTable, Key("Slug").Equal("xkcd"), Limit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var first, second []row
+	cursor, err := svc.QueryPageWithContext(ctx, in, "", &first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor == "" || strings.ContainsAny(cursor, "+/=") {
+		t.Errorf(`cursor: want a URL-safe cursor, got: "%s"`, cursor)
+	}
+	if in.ExclusiveStartKey != nil {
+		t.Error("input was changed")
+	}
+	cursor, err = svc.QueryPageWithContext(ctx, in, cursor, &second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor != "" {
+		t.Errorf(`cursor: want: "" after the last page, got: "%s"`, cursor)
+	}
+	if len(first) != len(pagesOutput1) || len(second) != len(pagesOutput2) {
+		t.Errorf(`items: want: %d and %d, got: %d and %d`, len(pagesOutput1), len(pagesOutput2), len(first), len(second))
+	}
+}
+
+func TestService_QueryPageOtherQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	ddbMock.EXPECT().QueryWithContext(ctx, gomock.Any()).Return(&dynamodb.QueryOutput{
+		Items:            pagesOutput1,
+		LastEvaluatedKey: map[string]*dynamodb.AttributeValue{"Slug": {S: aws.String("xkcd")}, "Added": {N: aws.String("42")}},
+	}, nil)
+
+	svc := Service{svc: ddbMock}
+	svc.SetCursorKey([]byte("secret"))
+	xkcd, err := NewQueryInput(pagesTable, Key("Slug").Equal("xkcd"), Limit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []row
+	cursor, err := svc.QueryPageWithContext(ctx, xkcd, "", &items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a validly signed cursor of one partition must not page another
+	smbc, err := NewQueryInput(pagesTable, Key("Slug").Equal("smbc"), Limit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.QueryPageWithContext(ctx, smbc, cursor, &items); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf(`err: want: ErrInvalidCursor, got: %v`, err)
+	}
+}
+
+func TestService_decodeCursor(t *testing.T) {
+	key := map[string]*dynamodb.AttributeValue{"Slug": {S: aws.String("xkcd")}, "Data": {B: []byte{0, 1}}}
+	signer := &Service{cursorKey: []byte("secret")}
+	scope := newCursorScope(&pagesTable, nil, aws.String("#0 = :0"), nil, aws.StringMap(map[string]string{"#0": "Slug"}), map[string]*dynamodb.AttributeValue{":0": {S: aws.String("xkcd")}})
+	signed, err := signer.encodeCursor(key, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned, err := (&Service{}).encodeCursor(key, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := (&Service{}).encodeCursor(map[string]*dynamodb.AttributeValue{"Slug": {S: aws.String("smbc")}}, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, sig, _ := strings.Cut(signed, ".")
+	otherIndex := scope
+	otherIndex.index = "by-title"
+	otherValue := newCursorScope(&pagesTable, nil, aws.String("#0 = :0"), nil, aws.StringMap(map[string]string{"#0": "Slug"}), map[string]*dynamodb.AttributeValue{":0": {S: aws.String("smbc")}})
+	otherFilter := newCursorScope(&pagesTable, nil, aws.String("#0 = :0"), aws.String("attribute_exists (#0)"), aws.StringMap(map[string]string{"#0": "Slug"}), map[string]*dynamodb.AttributeValue{":0": {S: aws.String("xkcd")}})
+	tests := map[string]struct {
+		svc    *Service
+		cursor string
+		scope  cursorScope
+		ok     bool
+	}{
+		"signed":         {signer, signed, scope, true},
+		"unsigned":       {&Service{}, unsigned, scope, true},
+		"missing sig":    {signer, unsigned, scope, false},
+		"wrong key":      {&Service{cursorKey: []byte("other")}, signed, scope, false},
+		"forged payload": {signer, other + "." + sig, scope, false},
+		"other index":    {signer, signed, otherIndex, false},
+		"other value":    {signer, signed, otherValue, false},
+		"other filter":   {&Service{}, unsigned, otherFilter, false},
+		"garbage":        {&Service{}, "!!!", scope, false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tt.svc.decodeCursor(tt.cursor, tt.scope)
+			if !tt.ok {
+				if !errors.Is(err, ErrInvalidCursor) {
+					t.Errorf(`err: want: ErrInvalidCursor, got: %v`, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if aws.StringValue(got["Slug"].S) != "xkcd" || string(got["Data"].B) != "\x00\x01" {
+				t.Errorf(`key: want: %v, got: %v`, key, got)
+			}
+		})
+	}
+}
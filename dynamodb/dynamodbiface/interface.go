@@ -9,18 +9,50 @@ import (
 
 // Service provides access to data in DynamoDB.
 type Service interface {
+	BatchGet(string, interface{}, interface{}, *aide.BatchOptions) error
+	BatchGetWithContext(context.Context, string, interface{}, interface{}, *aide.BatchOptions) error
+	BatchWrite(string, interface{}, interface{}, *aide.BatchOptions) error
+	BatchWriteWithContext(context.Context, string, interface{}, interface{}, *aide.BatchOptions) error
+	DeleteItem(*dynamodb.DeleteItemInput, interface{}) error
+	DeleteItemWithContext(context.Context, *dynamodb.DeleteItemInput, interface{}) error
+	Get(string, interface{}, interface{}, *aide.GetOptions) error
+	GetWithContext(context.Context, string, interface{}, interface{}, *aide.GetOptions) error
 	GetItem(*dynamodb.GetItemInput, interface{}) error
 	GetItemWithContext(context.Context, *dynamodb.GetItemInput, interface{}) error
+	ParallelScan(*dynamodb.ScanInput, *aide.ParallelScanOptions, func(map[string]*dynamodb.AttributeValue) error) error
+	ParallelScanWithContext(context.Context, *dynamodb.ScanInput, *aide.ParallelScanOptions, func(map[string]*dynamodb.AttributeValue) error) error
+	Put(string, interface{}, ...aide.Option) error
+	PutWithContext(context.Context, string, interface{}, ...aide.Option) error
 	PutItem(*dynamodb.PutItemInput, interface{}) (*dynamodb.PutItemOutput, error)
 	PutItemWithContext(context.Context, *dynamodb.PutItemInput, interface{}) (*dynamodb.PutItemOutput, error)
 	Query(*dynamodb.QueryInput, interface{}) error
 	QueryWithContext(context.Context, *dynamodb.QueryInput, interface{}) error
+	QueryPage(*dynamodb.QueryInput, string, interface{}) (string, error)
+	QueryPageWithContext(context.Context, *dynamodb.QueryInput, string, interface{}) (string, error)
 	QueryPages(in *dynamodb.QueryInput, outItems interface{}, outPager func(interface{}, bool) bool) error
 	QueryPagesWithContext(ctx context.Context, in *dynamodb.QueryInput, outItems interface{}, outPager func(interface{}, bool) bool) error
+	QueryStream(*dynamodb.QueryInput, aide.Pager) error
+	QueryStreamWithContext(context.Context, *dynamodb.QueryInput, aide.Pager) error
 	Scan(*dynamodb.ScanInput, interface{}) error
 	ScanWithContext(context.Context, *dynamodb.ScanInput, interface{}) error
+	ScanPage(*dynamodb.ScanInput, string, interface{}) (string, error)
+	ScanPageWithContext(context.Context, *dynamodb.ScanInput, string, interface{}) (string, error)
 	ScanPages(in *dynamodb.ScanInput, outItems interface{}, outPager func(interface{}, bool) bool) error
 	ScanPagesWithContext(ctx context.Context, in *dynamodb.ScanInput, outItems interface{}, outPager func(interface{}, bool) bool) error
+	ScanStream(*dynamodb.ScanInput, aide.Pager) error
+	ScanStreamWithContext(context.Context, *dynamodb.ScanInput, aide.Pager) error
+	SetCursorKey([]byte)
+	TransactGet(*aide.GetTx) error
+	TransactGetWithContext(context.Context, *aide.GetTx) error
+	TransactWrite(*aide.WriteTx) error
+	TransactWriteWithContext(context.Context, *aide.WriteTx) error
+	Update(string, interface{}, interface{}, ...aide.Option) error
+	UpdateWithContext(context.Context, string, interface{}, interface{}, ...aide.Option) error
+	UpdateItem(*dynamodb.UpdateItemInput, interface{}) error
+	UpdateItemWithContext(context.Context, *dynamodb.UpdateItemInput, interface{}) error
 }
 
-var _ Service = (*aide.Service)(nil) // test that the aide satisfies the interface
+var (
+	_ Service          = (*aide.Service)(nil) // test that the aide satisfies the interface
+	_ aide.ItemService = (Service)(nil)       // test that tables can be built on the interface
+)
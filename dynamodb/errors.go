@@ -0,0 +1,78 @@
+package dynamodb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+var (
+	// ErrNotFound is returned by GetItem and Get when there is no item with the key.
+	ErrNotFound = errors.New("dynamodb: item not found")
+
+	// ErrThrottled is matched, with errors.Is, by ThrottledErrors.
+	ErrThrottled = errors.New("dynamodb: request throttled")
+
+	// ErrConditionFailed is matched, with errors.Is, by ConditionFailedErrors.
+	ErrConditionFailed = errors.New("dynamodb: condition check failed")
+)
+
+// ThrottledError is the failure of an operation DynamoDB throttled, after
+// the retries of the SDK.
+type ThrottledError struct {
+	Op  string
+	Err error
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%s: throttled: %v", e.Op, e.Err)
+}
+
+// Is reports whether target is ErrThrottled.
+func (e *ThrottledError) Is(target error) bool {
+	return target == ErrThrottled
+}
+
+func (e *ThrottledError) Unwrap() error {
+	return e.Err
+}
+
+// ConditionFailedError is the failure of a write whose condition expression
+// did not hold.
+type ConditionFailedError struct {
+	Op  string
+	Err error
+}
+
+func (e *ConditionFailedError) Error() string {
+	return fmt.Sprintf("%s: condition check failed: %v", e.Op, e.Err)
+}
+
+// Is reports whether target is ErrConditionFailed.
+func (e *ConditionFailedError) Is(target error) bool {
+	return target == ErrConditionFailed
+}
+
+func (e *ConditionFailedError) Unwrap() error {
+	return e.Err
+}
+
+// wrapError returns err from the operation as a ThrottledError or
+// ConditionFailedError if it is one, and otherwise unchanged.
+func wrapError(op string, err error) error {
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) {
+		return err
+	}
+	switch aerr.Code() {
+	case dynamodb.ErrCodeProvisionedThroughputExceededException,
+		dynamodb.ErrCodeRequestLimitExceeded,
+		"ThrottlingException":
+		return &ThrottledError{Op: op, Err: err}
+	case dynamodb.ErrCodeConditionalCheckFailedException:
+		return &ConditionFailedError{Op: op, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cleardataeng/aidews/dynamodb/extmocks/github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/golang/mock/gomock"
+)
+
+func TestService_GetNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	ddbMock.EXPECT().GetItemWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *dynamodb.GetItemInput, _ ...request.Option) (*dynamodb.GetItemOutput, error) {
+			if !aws.BoolValue(in.ConsistentRead) {
+				t.Error("expected a consistent read")
+			}
+			if got := aws.StringValue(in.ProjectionExpression); got != "#p0, #p1" {
+				t.Errorf(`projection: want: "#p0, #p1", got: "%s"`, got)
+			}
+			if got := aws.StringValue(in.ExpressionAttributeNames["#p1"]); got != "title" {
+				t.Errorf(`#p1: want: "title", got: "%s"`, got)
+			}
+			if got := aws.StringValue(in.Key["Slug"].S); got != "xkcd" {
+				t.Errorf(`key: want: "xkcd", got: "%s"`, got)
+			}
+			return &dynamodb.GetItemOutput{}, nil
+		},
+	)
+
+	svc := Service{svc: ddbMock}
+	opts := &GetOptions{ConsistentRead: true, Projection: []string{"slug", "title"}}
+	err := svc.GetWithContext(ctx, pagesTable, struct{ Slug string }{"xkcd"}, &row{}, opts)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf(`err: want: ErrNotFound, got: %v`, err)
+	}
+}
+
+func TestService_PutItemErrors(t *testing.T) {
+	tests := map[string]struct {
+		code string
+		want error
+	}{
+		"throttled":        {dynamodb.ErrCodeProvisionedThroughputExceededException, ErrThrottled},
+		"condition failed": {dynamodb.ErrCodeConditionalCheckFailedException, ErrConditionFailed},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+			cause := awserr.New(tt.code, "", nil)
+			ddbMock.EXPECT().PutItemWithContext(ctx, gomock.Any()).Return(nil, cause)
+
+			svc := Service{svc: ddbMock}
+			_, err := svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{TableName: &pagesTable}, row{Slug: "xkcd"})
+			if !errors.Is(err, tt.want) {
+				t.Errorf(`err: want: %v, got: %v`, tt.want, err)
+			}
+			var aerr awserr.Error
+			if !errors.As(err, &aerr) || aerr.Code() != tt.code {
+				t.Errorf(`err: want to unwrap to code %s, got: %v`, tt.code, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,439 @@
+package dynamodb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// KeyAttr is a key attribute in a key condition. The conditions made from it
+// are builders of the SDK expression package, so they combine with And and
+// with builders made with that package directly.
+type KeyAttr string
+
+// Key returns the key attribute with the name.
+func Key(name string) KeyAttr {
+	return KeyAttr(name)
+}
+
+func (k KeyAttr) key() expression.KeyBuilder { return expression.Key(string(k)) }
+
+// Equal matches items whose key is v.
+func (k KeyAttr) Equal(v interface{}) expression.KeyConditionBuilder {
+	return k.key().Equal(expression.Value(v))
+}
+
+// LessThan matches items whose sort key is less than v.
+func (k KeyAttr) LessThan(v interface{}) expression.KeyConditionBuilder {
+	return k.key().LessThan(expression.Value(v))
+}
+
+// LessThanEqual matches items whose sort key is at most v.
+func (k KeyAttr) LessThanEqual(v interface{}) expression.KeyConditionBuilder {
+	return k.key().LessThanEqual(expression.Value(v))
+}
+
+// GreaterThan matches items whose sort key is more than v.
+func (k KeyAttr) GreaterThan(v interface{}) expression.KeyConditionBuilder {
+	return k.key().GreaterThan(expression.Value(v))
+}
+
+// GreaterThanEqual matches items whose sort key is at least v.
+func (k KeyAttr) GreaterThanEqual(v interface{}) expression.KeyConditionBuilder {
+	return k.key().GreaterThanEqual(expression.Value(v))
+}
+
+// Between matches items whose sort key is from lower to upper inclusive.
+func (k KeyAttr) Between(lower, upper interface{}) expression.KeyConditionBuilder {
+	return k.key().Between(expression.Value(lower), expression.Value(upper))
+}
+
+// BeginsWith matches items whose string sort key has the prefix.
+func (k KeyAttr) BeginsWith(prefix string) expression.KeyConditionBuilder {
+	return k.key().BeginsWith(prefix)
+}
+
+// AttrName is an attribute in a filter or condition. Names may be document
+// paths, such as "address.city" or "tags[0]". Conditions made from it can be
+// combined with And, Or and Not.
+type AttrName string
+
+// Attr returns the attribute with the name.
+func Attr(name string) AttrName {
+	return AttrName(name)
+}
+
+func (a AttrName) name() expression.NameBuilder { return expression.Name(string(a)) }
+
+// Equal matches items whose attribute is v.
+func (a AttrName) Equal(v interface{}) expression.ConditionBuilder {
+	return a.name().Equal(expression.Value(v))
+}
+
+// NotEqual matches items whose attribute is not v.
+func (a AttrName) NotEqual(v interface{}) expression.ConditionBuilder {
+	return a.name().NotEqual(expression.Value(v))
+}
+
+// LessThan matches items whose attribute is less than v.
+func (a AttrName) LessThan(v interface{}) expression.ConditionBuilder {
+	return a.name().LessThan(expression.Value(v))
+}
+
+// LessThanEqual matches items whose attribute is at most v.
+func (a AttrName) LessThanEqual(v interface{}) expression.ConditionBuilder {
+	return a.name().LessThanEqual(expression.Value(v))
+}
+
+// GreaterThan matches items whose attribute is more than v.
+func (a AttrName) GreaterThan(v interface{}) expression.ConditionBuilder {
+	return a.name().GreaterThan(expression.Value(v))
+}
+
+// GreaterThanEqual matches items whose attribute is at least v.
+func (a AttrName) GreaterThanEqual(v interface{}) expression.ConditionBuilder {
+	return a.name().GreaterThanEqual(expression.Value(v))
+}
+
+// Between matches items whose attribute is from lower to upper inclusive.
+func (a AttrName) Between(lower, upper interface{}) expression.ConditionBuilder {
+	return a.name().Between(expression.Value(lower), expression.Value(upper))
+}
+
+// In matches items whose attribute is one of the values.
+func (a AttrName) In(v interface{}, more ...interface{}) expression.ConditionBuilder {
+	rest := make([]expression.OperandBuilder, len(more))
+	for i, m := range more {
+		rest[i] = expression.Value(m)
+	}
+	return a.name().In(expression.Value(v), rest...)
+}
+
+// BeginsWith matches items whose string attribute has the prefix.
+func (a AttrName) BeginsWith(prefix string) expression.ConditionBuilder {
+	return a.name().BeginsWith(prefix)
+}
+
+// Contains matches items whose string attribute contains v as a substring,
+// or whose set or list attribute contains v as an element.
+func (a AttrName) Contains(v string) expression.ConditionBuilder {
+	return a.name().Contains(v)
+}
+
+// Exists matches items with the attribute.
+func (a AttrName) Exists() expression.ConditionBuilder {
+	return a.name().AttributeExists()
+}
+
+// NotExists matches items without the attribute.
+func (a AttrName) NotExists() expression.ConditionBuilder {
+	return a.name().AttributeNotExists()
+}
+
+// Set returns an update setting the attribute to v. Further changes are
+// chained with the methods of expression.UpdateBuilder.
+func Set(name string, v interface{}) expression.UpdateBuilder {
+	return expression.Set(expression.Name(name), expression.Value(v))
+}
+
+// Remove returns an update removing the attribute.
+func Remove(name string) expression.UpdateBuilder {
+	return expression.Remove(expression.Name(name))
+}
+
+// Option adds an expression or setting to an input built by NewQueryInput,
+// NewScanInput, NewPutItemInput, NewUpdateItemInput or NewDeleteItemInput.
+// Attribute names and values in expressions are replaced by generated
+// placeholders.
+type Option func(*exprInput)
+
+// exprInput collects the options of an input.
+type exprInput struct {
+	key            *expression.KeyConditionBuilder
+	filter         *expression.ConditionBuilder
+	condition      *expression.ConditionBuilder
+	projection     *expression.ProjectionBuilder
+	update         *expression.UpdateBuilder
+	index          string
+	limit          int64
+	consistentRead bool
+	descending     bool
+	returnValues   string
+}
+
+// Filter returns an Option filtering the items read by a query or scan.
+// Filtered items still count towards read capacity and Limit.
+func Filter(c expression.ConditionBuilder) Option {
+	return func(e *exprInput) { e.filter = &c }
+}
+
+// If returns an Option making a put or update conditional; a write whose
+// condition does not hold fails with a ConditionFailedError.
+func If(c expression.ConditionBuilder) Option {
+	return func(e *exprInput) { e.condition = &c }
+}
+
+// andIf returns an Option adding the condition to any given with If.
+func andIf(c expression.ConditionBuilder) Option {
+	return func(e *exprInput) {
+		cond := c
+		if e.condition != nil {
+			cond = e.condition.And(c)
+		}
+		e.condition = &cond
+	}
+}
+
+// Projection returns an Option reading only the named attributes in a query
+// or scan.
+func Projection(name string, more ...string) Option {
+	names := make([]expression.NameBuilder, len(more))
+	for i, m := range more {
+		names[i] = expression.Name(m)
+	}
+	p := expression.NamesList(expression.Name(name), names...)
+	return func(e *exprInput) { e.projection = &p }
+}
+
+// Index returns an Option querying or scanning the named secondary index.
+func Index(name string) Option {
+	return func(e *exprInput) { e.index = name }
+}
+
+// Limit returns an Option reading at most n items per page of a query or scan.
+func Limit(n int64) Option {
+	return func(e *exprInput) { e.limit = n }
+}
+
+// ConsistentRead returns an Option reading with strong consistency in a query
+// or scan.
+func ConsistentRead() Option {
+	return func(e *exprInput) { e.consistentRead = true }
+}
+
+// ReturnValues returns an Option choosing the attributes a put, update or
+// delete returns, e.g. dynamodb.ReturnValueAllNew.
+func ReturnValues(v string) Option {
+	return func(e *exprInput) { e.returnValues = v }
+}
+
+// Descending returns an Option reading a query in descending sort key order.
+func Descending() Option {
+	return func(e *exprInput) { e.descending = true }
+}
+
+// SetAll returns an update setting each attribute of item, a struct or map,
+// except the named attributes, usually the key. Empty struct fields tagged
+// omitempty are left unchanged, so a partial struct updates only its fields.
+func SetAll(item interface{}, except ...string) (expression.UpdateBuilder, error) {
+	attrs, err := dynamodbattribute.MarshalMap(item)
+	if err != nil {
+		return expression.UpdateBuilder{}, err
+	}
+	update, n := setAll(attrs, except)
+	if n == 0 {
+		return update, errors.New("update: no attributes to set")
+	}
+	return update, nil
+}
+
+// setAll returns an update setting the attributes, in name order, except
+// the named ones, and the number set.
+func setAll(attrs map[string]*dynamodb.AttributeValue, except []string) (expression.UpdateBuilder, int) {
+	var update expression.UpdateBuilder
+	skip := make(map[string]bool, len(except))
+	for _, name := range except {
+		skip[name] = true
+	}
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		if !skip[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		update = update.Set(expression.Name(name), expression.Value(attrs[name]))
+	}
+	return update, len(names)
+}
+
+// NewQueryInput returns the input of a query of the table for the items
+// matching the key condition, e.g. Key("pk").Equal(x).
+func NewQueryInput(table string, key expression.KeyConditionBuilder, opts ...Option) (*dynamodb.QueryInput, error) {
+	e := exprOptions(opts)
+	if e.condition != nil || e.returnValues != "" {
+		return nil, errors.New("query: If and ReturnValues are not query options")
+	}
+	e.key = &key
+	x, err := e.build()
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	in := &dynamodb.QueryInput{
+		ExpressionAttributeNames:  x.Names(),
+		ExpressionAttributeValues: x.Values(),
+		FilterExpression:          x.Filter(),
+		KeyConditionExpression:    x.KeyCondition(),
+		ProjectionExpression:      x.Projection(),
+		TableName:                 aws.String(table),
+	}
+	e.applyRead(&in.IndexName, &in.Limit, &in.ConsistentRead)
+	if e.descending {
+		in.ScanIndexForward = aws.Bool(false)
+	}
+	return in, nil
+}
+
+// NewScanInput returns the input of a scan of the table.
+func NewScanInput(table string, opts ...Option) (*dynamodb.ScanInput, error) {
+	e := exprOptions(opts)
+	if e.condition != nil || e.descending || e.returnValues != "" {
+		return nil, errors.New("scan: only Filter, Projection, Index, Limit and ConsistentRead are scan options")
+	}
+	in := &dynamodb.ScanInput{TableName: aws.String(table)}
+	if e.filter != nil || e.projection != nil {
+		x, err := e.build()
+		if err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		in.ExpressionAttributeNames = x.Names()
+		in.ExpressionAttributeValues = x.Values()
+		in.FilterExpression = x.Filter()
+		in.ProjectionExpression = x.Projection()
+	}
+	e.applyRead(&in.IndexName, &in.Limit, &in.ConsistentRead)
+	return in, nil
+}
+
+// NewPutItemInput returns the input of a put to the table, for use with
+// PutItem, which sets the item.
+func NewPutItemInput(table string, opts ...Option) (*dynamodb.PutItemInput, error) {
+	e := exprOptions(opts)
+	if e.readOptions() {
+		return nil, errors.New("put: If and ReturnValues are the only put options")
+	}
+	in := &dynamodb.PutItemInput{TableName: aws.String(table)}
+	if e.returnValues != "" {
+		in.ReturnValues = aws.String(e.returnValues)
+	}
+	if e.condition != nil {
+		x, err := e.build()
+		if err != nil {
+			return nil, fmt.Errorf("put: %w", err)
+		}
+		in.ConditionExpression = x.Condition()
+		in.ExpressionAttributeNames = x.Names()
+		in.ExpressionAttributeValues = x.Values()
+	}
+	return in, nil
+}
+
+// NewUpdateItemInput returns the input of an update of the item with the key,
+// a struct or map of the key attributes, in the table.
+func NewUpdateItemInput(table string, key interface{}, update expression.UpdateBuilder, opts ...Option) (*dynamodb.UpdateItemInput, error) {
+	e := exprOptions(opts)
+	if e.readOptions() {
+		return nil, errors.New("update: If and ReturnValues are the only update options")
+	}
+	k, err := dynamodbattribute.MarshalMap(key)
+	if err != nil {
+		return nil, err
+	}
+	e.update = &update
+	x, err := e.build()
+	if err != nil {
+		return nil, fmt.Errorf("update: %w", err)
+	}
+	in := &dynamodb.UpdateItemInput{
+		ConditionExpression:       x.Condition(),
+		ExpressionAttributeNames:  x.Names(),
+		ExpressionAttributeValues: x.Values(),
+		Key:                       k,
+		TableName:                 aws.String(table),
+		UpdateExpression:          x.Update(),
+	}
+	if e.returnValues != "" {
+		in.ReturnValues = aws.String(e.returnValues)
+	}
+	return in, nil
+}
+
+// NewDeleteItemInput returns the input of a delete of the item with the key,
+// a struct or map of the key attributes, from the table.
+func NewDeleteItemInput(table string, key interface{}, opts ...Option) (*dynamodb.DeleteItemInput, error) {
+	e := exprOptions(opts)
+	if e.readOptions() {
+		return nil, errors.New("delete: If and ReturnValues are the only delete options")
+	}
+	k, err := dynamodbattribute.MarshalMap(key)
+	if err != nil {
+		return nil, err
+	}
+	in := &dynamodb.DeleteItemInput{Key: k, TableName: aws.String(table)}
+	if e.condition != nil {
+		x, err := e.build()
+		if err != nil {
+			return nil, fmt.Errorf("delete: %w", err)
+		}
+		in.ConditionExpression = x.Condition()
+		in.ExpressionAttributeNames = x.Names()
+		in.ExpressionAttributeValues = x.Values()
+	}
+	if e.returnValues != "" {
+		in.ReturnValues = aws.String(e.returnValues)
+	}
+	return in, nil
+}
+
+func exprOptions(opts []Option) *exprInput {
+	e := &exprInput{}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// readOptions reports whether any option only for reads is set.
+func (e *exprInput) readOptions() bool {
+	return e.filter != nil || e.projection != nil || e.index != "" || e.limit > 0 || e.consistentRead || e.descending
+}
+
+// applyRead sets the read settings of a query or scan input.
+func (e *exprInput) applyRead(index **string, limit **int64, consistentRead **bool) {
+	if e.index != "" {
+		*index = aws.String(e.index)
+	}
+	if e.limit > 0 {
+		*limit = aws.Int64(e.limit)
+	}
+	if e.consistentRead {
+		*consistentRead = aws.Bool(true)
+	}
+}
+
+// build builds the expressions set, with shared placeholders.
+func (e *exprInput) build() (expression.Expression, error) {
+	b := expression.NewBuilder()
+	if e.key != nil {
+		b = b.WithKeyCondition(*e.key)
+	}
+	if e.filter != nil {
+		b = b.WithFilter(*e.filter)
+	}
+	if e.condition != nil {
+		b = b.WithCondition(*e.condition)
+	}
+	if e.projection != nil {
+		b = b.WithProjection(*e.projection)
+	}
+	if e.update != nil {
+		b = b.WithUpdate(*e.update)
+	}
+	return b.Build()
+}
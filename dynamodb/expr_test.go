@@ -0,0 +1,76 @@
+package dynamodb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+func TestNewQueryInput(t *testing.T) {
+	in, err := NewQueryInput(pagesTable,
+		Key("slug").Equal("xkcd").And(Key("rank").Between(1, 10)),
+		Filter(Attr("title").BeginsWith("Some").Or(Attr("title").NotExists())),
+		Projection("slug", "title"),
+		Index("by-rank"),
+		Limit(25),
+		Descending(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &dynamodb.QueryInput{
+		ExpressionAttributeNames: map[string]*string{
+			"#0": aws.String("title"),
+			"#1": aws.String("slug"),
+			"#2": aws.String("rank"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":0": {S: aws.String("Some")},
+			":1": {S: aws.String("xkcd")},
+			":2": {N: aws.String("1")},
+			":3": {N: aws.String("10")},
+		},
+		FilterExpression:       aws.String("(begins_with (#0, :0)) OR (attribute_not_exists (#0))"),
+		IndexName:              aws.String("by-rank"),
+		KeyConditionExpression: aws.String("(#1 = :1) AND (#2 BETWEEN :2 AND :3)"),
+		Limit:                  aws.Int64(25),
+		ProjectionExpression:   aws.String("#1, #0"),
+		ScanIndexForward:       aws.Bool(false),
+		TableName:              aws.String(pagesTable),
+	}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("input:\nwant: %v\ngot: %v", want, in)
+	}
+}
+
+func TestNewUpdateItemInput(t *testing.T) {
+	update := Set("title", "gaming").Add(expression.Name("views"), expression.Value(1))
+	in, err := NewUpdateItemInput(pagesTable, map[string]string{"slug": "wasd"}, update, If(Attr("slug").Exists()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := aws.StringValue(in.ConditionExpression); got != "attribute_exists (#0)" {
+		t.Errorf(`condition: want: "attribute_exists (#0)", got: "%s"`, got)
+	}
+	if got := aws.StringValue(in.UpdateExpression); got != "ADD #1 :0\nSET #2 = :1\n" {
+		t.Errorf(`update: want: "ADD #1 :0\nSET #2 = :1\n", got: %q`, got)
+	}
+	if got := aws.StringValue(in.Key["slug"].S); got != "wasd" {
+		t.Errorf(`key: want: "wasd", got: "%s"`, got)
+	}
+}
+
+func TestNewInputInvalidOptions(t *testing.T) {
+	if _, err := NewScanInput(pagesTable, Descending()); err == nil {
+		t.Error("scan: expected error for Descending")
+	}
+	if _, err := NewPutItemInput(pagesTable, Filter(Attr("slug").Exists())); err == nil {
+		t.Error("put: expected error for Filter")
+	}
+	if _, err := NewQueryInput(pagesTable, Key("slug").Equal("xkcd"), If(Attr("slug").Exists())); err == nil {
+		t.Error("query: expected error for If")
+	}
+}
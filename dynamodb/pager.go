@@ -0,0 +1,178 @@
+package dynamodb
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// PageInfo describes a page of a query or scan.
+type PageInfo struct {
+	// Number is the position of the page, from 1.
+	Number int
+
+	// Count is the number of items in the page, and ScannedCount the number
+	// read before any filter was applied.
+	Count        int64
+	ScannedCount int64
+
+	// ConsumedCapacity is set when the input asks for it with
+	// ReturnConsumedCapacity.
+	ConsumedCapacity *dynamodb.ConsumedCapacity
+
+	// LastEvaluatedKey is the key of the last item read; it is empty on the
+	// final page.
+	LastEvaluatedKey map[string]*dynamodb.AttributeValue
+
+	// Last reports whether this is the final page.
+	Last bool
+}
+
+// Pager receives the items and pages of QueryStream and ScanStream. Either
+// callback may be nil; returning false from one stops the query or scan.
+type Pager struct {
+	// New returns a pointer to the new value each item is unmarshalled into.
+	// Items are maps of type map[string]interface{} when New is nil.
+	New func() interface{}
+
+	// Item is called with each item, and whether it is the final item of the
+	// query or scan. Telling this may mean reading the next page before the
+	// final item of a page is passed to Item.
+	Item func(item interface{}, last bool) bool
+
+	// Page is called with each page, before its items.
+	Page func(PageInfo) bool
+}
+
+// QueryStream queries the table, passing each page and item to the Pager.
+// See QueryStreamWithContext.
+func (svc *Service) QueryStream(in *dynamodb.QueryInput, p Pager) error {
+	return svc.QueryStreamWithContext(context.TODO(), in, p)
+}
+
+// QueryStreamWithContext queries the table, passing each page and item to the
+// Pager.
+//
+// Example:
+//
+//	err := svc.QueryStreamWithContext(ctx, in, Pager{
+//		New: func() interface{} { return &Item{} },
+//		Item: func(item interface{}, last bool) bool {
+//			items = append(items, item.(*Item))
+//			return true
+//		},
+//		Page: func(p PageInfo) bool {
+//			consumed += aws.Float64Value(p.ConsumedCapacity.CapacityUnits)
+//			return true
+//		},
+//	})
+func (svc *Service) QueryStreamWithContext(ctx context.Context, in *dynamodb.QueryInput, p Pager) error {
+	s := &stream{Pager: p}
+	err := svc.svc.QueryPagesWithContext(ctx, in, func(out *dynamodb.QueryOutput, last bool) bool {
+		return s.page(out.Items, PageInfo{
+			Count:            aws.Int64Value(out.Count),
+			ScannedCount:     aws.Int64Value(out.ScannedCount),
+			ConsumedCapacity: out.ConsumedCapacity,
+			LastEvaluatedKey: out.LastEvaluatedKey,
+		}, last)
+	})
+	if err != nil {
+		return wrapError("Query", err)
+	}
+	return s.err
+}
+
+// ScanStream scans the table, passing each page and item to the Pager.
+// See ScanStreamWithContext.
+func (svc *Service) ScanStream(in *dynamodb.ScanInput, p Pager) error {
+	return svc.ScanStreamWithContext(context.TODO(), in, p)
+}
+
+// ScanStreamWithContext scans the table, passing each page and item to the
+// Pager. See QueryStreamWithContext.
+func (svc *Service) ScanStreamWithContext(ctx context.Context, in *dynamodb.ScanInput, p Pager) error {
+	s := &stream{Pager: p}
+	err := svc.svc.ScanPagesWithContext(ctx, in, func(out *dynamodb.ScanOutput, last bool) bool {
+		return s.page(out.Items, PageInfo{
+			Count:            aws.Int64Value(out.Count),
+			ScannedCount:     aws.Int64Value(out.ScannedCount),
+			ConsumedCapacity: out.ConsumedCapacity,
+			LastEvaluatedKey: out.LastEvaluatedKey,
+		}, last)
+	})
+	if err != nil {
+		return wrapError("Scan", err)
+	}
+	return s.err
+}
+
+// stream passes the pages of a query or scan to a Pager. The final item of
+// a page that is not the last is held until the next page is read, since it
+// is the final item of all if that page is empty.
+type stream struct {
+	Pager
+	pages int
+	held  map[string]*dynamodb.AttributeValue
+	err   error
+}
+
+// page is the pager function of the query or scan.
+func (s *stream) page(items []map[string]*dynamodb.AttributeValue, info PageInfo, last bool) bool {
+	s.pages++
+	info.Number = s.pages
+	info.Last = last
+	if s.held != nil {
+		held := s.held
+		s.held = nil
+		if !s.item(held, last && len(items) == 0) {
+			return false
+		}
+	}
+	if s.Page != nil && !s.Page(info) {
+		return false
+	}
+	if s.Item == nil {
+		return !last
+	}
+	for i, attrs := range items {
+		final := i == len(items)-1
+		if final && !last {
+			s.held = attrs
+			break
+		}
+		if !s.item(attrs, final) {
+			return false
+		}
+	}
+	return !last
+}
+
+// item unmarshals the attributes into a new item and passes it to Item.
+func (s *stream) item(attrs map[string]*dynamodb.AttributeValue, last bool) bool {
+	if s.New == nil {
+		item := map[string]interface{}{}
+		if s.err = dynamodbattribute.UnmarshalMap(attrs, &item); s.err != nil {
+			return false
+		}
+		return s.Item(item, last)
+	}
+	item := s.New()
+	if s.err = dynamodbattribute.UnmarshalMap(attrs, item); s.err != nil {
+		return false
+	}
+	return s.Item(item, last)
+}
+
+// reuse returns a New func returning item, a pointer, zeroed each time, so
+// no attribute of one item is carried into the next.
+func reuse(item interface{}) func() interface{} {
+	return func() interface{} {
+		if v := reflect.ValueOf(item); v.Kind() == reflect.Ptr && !v.IsNil() {
+			v.Elem().Set(reflect.Zero(v.Elem().Type()))
+		}
+		return item
+	}
+}
@@ -0,0 +1,98 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cleardataeng/aidews/dynamodb/extmocks/github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/golang/mock/gomock"
+)
+
+func TestService_QueryStream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	ddbMock.EXPECT().QueryPagesWithContext(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *dynamodb.QueryInput, f func(*dynamodb.QueryOutput, bool) bool, _ ...request.Option) error {
+			page := &dynamodb.QueryOutput{
+				Items:            pagesOutput1,
+				Count:            aws.Int64(2),
+				ScannedCount:     aws.Int64(3),
+				ConsumedCapacity: &dynamodb.ConsumedCapacity{CapacityUnits: aws.Float64(0.5)},
+				LastEvaluatedKey: pagesOutput1[1],
+			}
+			// the final page is empty, so the final item is on the first
+			if f(page, false) {
+				f(&dynamodb.QueryOutput{Count: aws.Int64(0), ScannedCount: aws.Int64(1)}, true)
+			}
+			return nil
+		},
+	)
+
+	var events []string
+	var items []*row
+	svc := Service{svc: ddbMock}
+	err := svc.QueryStreamWithContext(ctx, &dynamodb.QueryInput{TableName: &pagesTable}, Pager{
+		New: func() interface{} { return &row{} },
+		Item: func(item interface{}, last bool) bool {
+			items = append(items, item.(*row))
+			events = append(events, fmt.Sprintf("item %s %t", item.(*row).Slug, last))
+			return true
+		},
+		Page: func(p PageInfo) bool {
+			events = append(events, fmt.Sprintf("page %d %d/%d %t", p.Number, p.Count, p.ScannedCount, p.Last))
+			if p.Number == 1 && aws.Float64Value(p.ConsumedCapacity.CapacityUnits) != 0.5 {
+				t.Errorf(`consumed capacity: want: 0.5, got: %v`, p.ConsumedCapacity)
+			}
+			return true
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"page 1 2/3 false", "item xkcd false", "item hijk true", "page 2 0/1 true"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf(`events: want: %q, got: %q`, want, events)
+	}
+	if len(items) == 2 && items[0] == items[1] {
+		t.Error("items share a value")
+	}
+}
+
+func TestService_ScanPagesWithContextFresh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	ddbMock.EXPECT().ScanPagesWithContext(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *dynamodb.ScanInput, f func(*dynamodb.ScanOutput, bool) bool, _ ...request.Option) error {
+			f(&dynamodb.ScanOutput{Items: []map[string]*dynamodb.AttributeValue{
+				pagesOutput1[0],
+				{"slug": {S: aws.String("untitled")}},
+			}}, true)
+			return nil
+		},
+	)
+
+	var titles []string
+	var lasts []bool
+	svc := Service{svc: ddbMock}
+	err := svc.ScanPagesWithContext(ctx, &dynamodb.ScanInput{TableName: &pagesTable}, &row{}, func(item interface{}, last bool) bool {
+		titles = append(titles, item.(*row).Title)
+		lasts = append(lasts, last)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Some guy", ""}; !reflect.DeepEqual(titles, want) {
+		t.Errorf(`titles: want: %q, got: %q`, want, titles)
+	}
+	if want := []bool{false, true}; !reflect.DeepEqual(lasts, want) {
+		t.Errorf(`last: want: %v, got: %v`, want, lasts)
+	}
+}
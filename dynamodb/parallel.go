@@ -0,0 +1,217 @@
+package dynamodb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// defaultSegments is the number of segments, and of workers, of a
+// ParallelScan when not set.
+const defaultSegments = 4
+
+// ParallelScanOptions configures ParallelScan. The zero value scans four
+// segments at once without a rate limit.
+type ParallelScanOptions struct {
+	// Segments is the number of segments the table is divided into, the
+	// TotalSegments of each scan; 4 when zero.
+	Segments int
+
+	// Workers is the number of segments scanned at once, at most and by
+	// default Segments.
+	Workers int
+
+	// ReadCapacity limits the read capacity units consumed per second by all
+	// the segments together; there is no limit when zero.
+	ReadCapacity float64
+}
+
+func (opts *ParallelScanOptions) segments() int {
+	if opts == nil || opts.Segments <= 0 {
+		return defaultSegments
+	}
+	return opts.Segments
+}
+
+func (opts *ParallelScanOptions) workers() int {
+	segments := opts.segments()
+	if opts == nil || opts.Workers <= 0 || opts.Workers > segments {
+		return segments
+	}
+	return opts.Workers
+}
+
+// ParallelScan scans the table of in, usually built with NewScanInput, in
+// segments scanned at once, and calls fn with each item.
+// See ParallelScanWithContext.
+func (svc *Service) ParallelScan(in *dynamodb.ScanInput, opts *ParallelScanOptions, fn func(map[string]*dynamodb.AttributeValue) error) error {
+	return svc.ParallelScanWithContext(context.TODO(), in, opts, fn)
+}
+
+// ParallelScanWithContext scans the table of in, usually built with
+// NewScanInput, in segments scanned at once, and calls fn with each item. fn
+// is called from the calling goroutine, one item at a time, and segments wait
+// for it, so a slow fn slows the scan rather than filling memory. An error
+// from fn, a failed segment or the end of ctx stops every segment, and the
+// error is returned. Items are in no particular order. opts may be nil.
+//
+// fn is given the raw attributes of each item, which it may unmarshal with
+// dynamodbattribute. Table.ParallelScanSeq is the typed form, a sequence of
+// the unmarshalled items that can be ranged over.
+func (svc *Service) ParallelScanWithContext(ctx context.Context, in *dynamodb.ScanInput, opts *ParallelScanOptions, fn func(map[string]*dynamodb.AttributeValue) error) error {
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	total := opts.segments()
+	var limit *capacityLimiter
+	if opts != nil && opts.ReadCapacity > 0 {
+		limit = newCapacityLimiter(opts.ReadCapacity)
+	}
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var scanErr error
+	segments := make(chan int)
+	pages := make(chan []map[string]*dynamodb.AttributeValue, opts.workers())
+	for i := 0; i < opts.workers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for segment := range segments {
+				if err := svc.scanSegment(ctx, in, segment, total, limit, pages); err != nil {
+					once.Do(func() {
+						scanErr = err
+						cancel()
+					})
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(segments)
+		for i := 0; i < total; i++ {
+			select {
+			case segments <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(pages)
+	}()
+
+	var fnErr error
+	for page := range pages {
+		for _, item := range page {
+			if fnErr != nil {
+				break
+			}
+			if fnErr = fn(item); fnErr != nil {
+				cancel()
+			}
+		}
+	}
+	switch {
+	case fnErr != nil:
+		return fnErr
+	case parent.Err() != nil:
+		return parent.Err()
+	}
+	return scanErr
+}
+
+// scanSegment scans the segment of the total, sending each page of items to
+// pages until the segment or ctx ends.
+func (svc *Service) scanSegment(ctx context.Context, in *dynamodb.ScanInput, segment, total int, limit *capacityLimiter, pages chan<- []map[string]*dynamodb.AttributeValue) error {
+	seg := *in
+	seg.Segment = aws.Int64(int64(segment))
+	seg.TotalSegments = aws.Int64(int64(total))
+	if limit != nil {
+		seg.ReturnConsumedCapacity = aws.String(dynamodb.ReturnConsumedCapacityTotal)
+		if err := limit.wait(ctx); err != nil {
+			return err
+		}
+	}
+	pager := func(out *dynamodb.ScanOutput, last bool) bool {
+		if limit != nil && out.ConsumedCapacity != nil {
+			limit.consume(aws.Float64Value(out.ConsumedCapacity.CapacityUnits))
+		}
+		if len(out.Items) > 0 {
+			select {
+			case pages <- out.Items:
+			case <-ctx.Done():
+				return false
+			}
+		}
+		if last {
+			return false
+		}
+		return limit.wait(ctx) == nil
+	}
+	if err := svc.svc.ScanPagesWithContext(ctx, &seg, pager); err != nil {
+		return wrapError("Scan", err)
+	}
+	return ctx.Err()
+}
+
+// capacityLimiter limits the capacity units consumed per second. Since the
+// units a request consumes are known only after it is made, consumption may
+// run ahead of the rate, and wait then blocks until the debt is repaid.
+// A nil capacityLimiter has no limit.
+type capacityLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	available float64
+	last      time.Time
+}
+
+func newCapacityLimiter(rate float64) *capacityLimiter {
+	return &capacityLimiter{rate: rate, available: rate, last: time.Now()}
+}
+
+// refill adds the units accrued since the last refill, up to one second's.
+// l.mu must be held.
+func (l *capacityLimiter) refill() {
+	now := time.Now()
+	l.available += now.Sub(l.last).Seconds() * l.rate
+	if l.available > l.rate {
+		l.available = l.rate
+	}
+	l.last = now
+}
+
+// consume takes units from those available.
+func (l *capacityLimiter) consume(units float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	l.available -= units
+}
+
+// wait blocks until units are available or ctx ends.
+func (l *capacityLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		l.mu.Lock()
+		l.refill()
+		debt := -l.available
+		l.mu.Unlock()
+		if debt < 0 {
+			return nil
+		}
+		select {
+		case <-time.After(time.Duration(debt / l.rate * float64(time.Second))):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cleardataeng/aidews/dynamodb/extmocks/github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/golang/mock/gomock"
+)
+
+// scanSegmentPages returns a ScanPagesWithContext stub returning two pages of
+// two items from each segment, recording the segments scanned.
+func scanSegmentPages(t *testing.T, total int64, seen *sync.Map) func(context.Context, *dynamodb.ScanInput, func(*dynamodb.ScanOutput, bool) bool, ...request.Option) error {
+	return func(ctx context.Context, in *dynamodb.ScanInput, f func(*dynamodb.ScanOutput, bool) bool, _ ...request.Option) error {
+		if got := aws.Int64Value(in.TotalSegments); got != total {
+			t.Errorf(`total segments: want: %d, got: %d`, total, got)
+		}
+		segment := aws.Int64Value(in.Segment)
+		seen.Store(segment, true)
+		for page := 0; page < 2; page++ {
+			out := &dynamodb.ScanOutput{ConsumedCapacity: &dynamodb.ConsumedCapacity{CapacityUnits: aws.Float64(1)}}
+			for i := 0; i < 2; i++ {
+				out.Items = append(out.Items, map[string]*dynamodb.AttributeValue{
+					"Slug": {S: aws.String(fmt.Sprintf("%d-%d-%d", segment, page, i))},
+				})
+			}
+			if !f(out, page == 1) {
+				break
+			}
+		}
+		return nil
+	}
+}
+
+func TestService_ParallelScan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	var seen sync.Map
+	ddbMock.EXPECT().ScanPagesWithContext(gomock.Any(), gomock.Any(), gomock.Any()).Times(5).DoAndReturn(scanSegmentPages(t, 5, &seen))
+
+	svc := Service{svc: ddbMock}
+	in, _ := NewScanInput(pagesTable)
+	slugs := map[string]bool{}
+	err := svc.ParallelScanWithContext(ctx, in, &ParallelScanOptions{Segments: 5, Workers: 2}, func(item map[string]*dynamodb.AttributeValue) error {
+		slugs[aws.StringValue(item["Slug"].S)] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slugs) != 20 {
+		t.Errorf(`items: want: 20, got: %d`, len(slugs))
+	}
+	for i := int64(0); i < 5; i++ {
+		if _, ok := seen.Load(i); !ok {
+			t.Errorf("segment %d not scanned", i)
+		}
+	}
+	if in.Segment != nil {
+		t.Error("input was changed")
+	}
+}
+
+func TestService_ParallelScanStop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	var seen sync.Map
+	ddbMock.EXPECT().ScanPagesWithContext(gomock.Any(), gomock.Any(), gomock.Any()).MaxTimes(100).DoAndReturn(scanSegmentPages(t, 100, &seen))
+
+	svc := Service{svc: ddbMock}
+	stop := errors.New("stop")
+	calls := 0
+	err := svc.ParallelScanWithContext(ctx, &dynamodb.ScanInput{}, &ParallelScanOptions{Segments: 100}, func(map[string]*dynamodb.AttributeValue) error {
+		calls++
+		return stop
+	})
+	if err != stop {
+		t.Errorf(`err: want: %v, got: %v`, stop, err)
+	}
+	if calls != 1 {
+		t.Errorf(`calls: want: 1, got: %d`, calls)
+	}
+}
+
+func TestTable_ParallelScanSeq(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	var seen sync.Map
+	pages := scanSegmentPages(t, 2, &seen)
+	ddbMock.EXPECT().ScanPagesWithContext(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).DoAndReturn(
+		func(ctx context.Context, in *dynamodb.ScanInput, f func(*dynamodb.ScanOutput, bool) bool, opts ...request.Option) error {
+			if got := aws.StringValue(in.ReturnConsumedCapacity); got != dynamodb.ReturnConsumedCapacityTotal {
+				t.Errorf(`consumed capacity: want: TOTAL, got: "%s"`, got)
+			}
+			return pages(ctx, in, f, opts...)
+		},
+	)
+
+	table := NewTable[row](&Service{svc: ddbMock}, pagesTable)
+	n := 0
+	for item, err := range table.ParallelScanSeq(ctx, &ParallelScanOptions{Segments: 2, ReadCapacity: 1000}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.Slug == "" {
+			t.Error("item not unmarshalled")
+		}
+		n++
+	}
+	if n != 8 {
+		t.Errorf(`items: want: 8, got: %d`, n)
+	}
+}
+
+func TestCapacityLimiter(t *testing.T) {
+	l := newCapacityLimiter(100)
+	l.consume(110) // 10 units of debt take 100ms to repay
+	start := time.Now()
+	if err := l.wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if waited := time.Since(start); waited < 80*time.Millisecond {
+		t.Errorf(`wait: want about 100ms, got: %v`, waited)
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	l.consume(100)
+	if err := l.wait(canceled); err != context.Canceled {
+		t.Errorf(`err: want: %v, got: %v`, context.Canceled, err)
+	}
+}
@@ -0,0 +1,228 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"iter"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// ItemService is the part of Service a Table is built on. It is satisfied by
+// Service and by dynamodbiface.Service, so tables can be tested with mocks.
+type ItemService interface {
+	BatchGetWithContext(context.Context, string, interface{}, interface{}, *BatchOptions) error
+	BatchWriteWithContext(context.Context, string, interface{}, interface{}, *BatchOptions) error
+	GetWithContext(context.Context, string, interface{}, interface{}, *GetOptions) error
+	GetItemWithContext(context.Context, *dynamodb.GetItemInput, interface{}) error
+	ParallelScanWithContext(context.Context, *dynamodb.ScanInput, *ParallelScanOptions, func(map[string]*dynamodb.AttributeValue) error) error
+	PutWithContext(context.Context, string, interface{}, ...Option) error
+	QueryPageWithContext(context.Context, *dynamodb.QueryInput, string, interface{}) (string, error)
+	QueryStreamWithContext(context.Context, *dynamodb.QueryInput, Pager) error
+	ScanPageWithContext(context.Context, *dynamodb.ScanInput, string, interface{}) (string, error)
+	ScanStreamWithContext(context.Context, *dynamodb.ScanInput, Pager) error
+	UpdateWithContext(context.Context, string, interface{}, interface{}, ...Option) error
+}
+
+// Table provides typed access to a DynamoDB table of items of type T, which
+// are marshalled with dynamodbattribute.
+//
+// Items with a version field are locked optimistically and have the field
+// advanced on Put and Update, so T must then be a pointer type, e.g.
+// Table[*Page]. A Table[Page] of such items compiles, but its Put and Update
+// fail.
+type Table[T any] struct {
+	name string
+	svc  ItemService
+}
+
+// NewTable returns a pointer to a new Table of the named table, using svc.
+func NewTable[T any](svc ItemService, name string) *Table[T] {
+	return &Table[T]{name: name, svc: svc}
+}
+
+// Name returns the name of the table.
+func (t *Table[T]) Name() string {
+	return t.name
+}
+
+// Get gets the item with the key, a struct or map of the key attributes.
+// opts may be nil. ErrNotFound is returned if there is no item with the key.
+func (t *Table[T]) Get(ctx context.Context, key interface{}, opts *GetOptions) (T, error) {
+	var item T
+	err := t.svc.GetWithContext(ctx, t.name, key, &item, opts)
+	return item, err
+}
+
+// Put puts the item, replacing any with the same key, optionally conditional
+// with If. If T has a version field, the put is locked optimistically and
+// the field is advanced; see Service.Put.
+func (t *Table[T]) Put(ctx context.Context, item T, opts ...Option) error {
+	return t.svc.PutWithContext(ctx, t.name, item, opts...)
+}
+
+// Update sets the non-empty attributes of item, a partial T, in the item with
+// the key, a struct or map of the key attributes. As with Put, a version
+// field is locked optimistically. See Service.Update.
+func (t *Table[T]) Update(ctx context.Context, key interface{}, item T, opts ...Option) error {
+	return t.svc.UpdateWithContext(ctx, t.name, key, item, opts...)
+}
+
+// BatchGet gets the items with the keys, a slice of structs or maps of the
+// key attributes, in no particular order. See Service.BatchGet.
+func (t *Table[T]) BatchGet(ctx context.Context, keys interface{}, opts *BatchOptions) ([]T, error) {
+	var items []T
+	err := t.svc.BatchGetWithContext(ctx, t.name, keys, &items, opts)
+	return items, err
+}
+
+// BatchWrite puts the items and deletes the items with the keys, a slice of
+// structs or maps of the key attributes; either may be nil. See
+// Service.BatchWrite.
+func (t *Table[T]) BatchWrite(ctx context.Context, puts []T, deletes interface{}, opts *BatchOptions) error {
+	return t.svc.BatchWriteWithContext(ctx, t.name, puts, deletes, opts)
+}
+
+// Query queries the table for the items matching the key condition, e.g.
+// Key("pk").Equal(x), and returns them all. See NewQueryInput.
+func (t *Table[T]) Query(ctx context.Context, key expression.KeyConditionBuilder, opts ...Option) ([]T, error) {
+	return collect(t.QuerySeq(ctx, key, opts...))
+}
+
+// QuerySeq returns a sequence of the items matching the key condition, read
+// a page at a time as the sequence is ranged over. An error ends the sequence.
+//
+// Example:
+//
+//	for item, err := range table.QuerySeq(ctx, Key("pk").Equal(x), Filter(Attr("state").Equal("CA"))) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(item.Title)
+//	}
+func (t *Table[T]) QuerySeq(ctx context.Context, key expression.KeyConditionBuilder, opts ...Option) iter.Seq2[T, error] {
+	in, err := NewQueryInput(t.name, key, opts...)
+	if err != nil {
+		return failed[T](err)
+	}
+	return seq[T](func(p Pager) error {
+		return t.svc.QueryStreamWithContext(ctx, in, p)
+	})
+}
+
+// QueryPage returns one page of the items matching the key condition, from
+// the cursor, and the cursor of the next page, which is empty after the last.
+// The page size is set with Limit. See Service.QueryPage.
+func (t *Table[T]) QueryPage(ctx context.Context, key expression.KeyConditionBuilder, cursor string, opts ...Option) ([]T, string, error) {
+	in, err := NewQueryInput(t.name, key, opts...)
+	if err != nil {
+		return nil, "", err
+	}
+	var items []T
+	next, err := t.svc.QueryPageWithContext(ctx, in, cursor, &items)
+	return items, next, err
+}
+
+// Scan scans the table and returns every item, or those passing a Filter.
+// See NewScanInput.
+func (t *Table[T]) Scan(ctx context.Context, opts ...Option) ([]T, error) {
+	return collect(t.ScanSeq(ctx, opts...))
+}
+
+// ScanSeq returns a sequence of the items of the scan, read a page at a time
+// as the sequence is ranged over. An error ends the sequence. See QuerySeq.
+func (t *Table[T]) ScanSeq(ctx context.Context, opts ...Option) iter.Seq2[T, error] {
+	in, err := NewScanInput(t.name, opts...)
+	if err != nil {
+		return failed[T](err)
+	}
+	return seq[T](func(p Pager) error {
+		return t.svc.ScanStreamWithContext(ctx, in, p)
+	})
+}
+
+// ScanPage returns one page of the items of the scan, from the cursor, and the
+// cursor of the next page. See QueryPage.
+func (t *Table[T]) ScanPage(ctx context.Context, cursor string, opts ...Option) ([]T, string, error) {
+	in, err := NewScanInput(t.name, opts...)
+	if err != nil {
+		return nil, "", err
+	}
+	var items []T
+	next, err := t.svc.ScanPageWithContext(ctx, in, cursor, &items)
+	return items, next, err
+}
+
+// ParallelScanSeq returns a sequence of the items of a scan of the table in
+// segments read at once, in no particular order. Segments wait while the
+// sequence is not ranged over, and stop when it is broken out of. An error
+// ends the sequence. See Service.ParallelScan.
+func (t *Table[T]) ParallelScanSeq(ctx context.Context, opts *ParallelScanOptions, scanOpts ...Option) iter.Seq2[T, error] {
+	in, err := NewScanInput(t.name, scanOpts...)
+	if err != nil {
+		return failed[T](err)
+	}
+	return func(yield func(T, error) bool) {
+		stopped := false
+		err := t.svc.ParallelScanWithContext(ctx, in, opts, func(attrs map[string]*dynamodb.AttributeValue) error {
+			var item T
+			if err := dynamodbattribute.UnmarshalMap(attrs, &item); err != nil {
+				return err
+			}
+			if !yield(item, nil) {
+				stopped = true
+				return errStopped
+			}
+			return nil
+		})
+		if err != nil && !stopped {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+// errStopped stops a parallel scan whose sequence is no longer ranged over.
+var errStopped = errors.New("dynamodb: scan stopped")
+
+// seq adapts a stream call to a sequence of its items.
+func seq[T any](stream func(Pager) error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		stopped := false
+		err := stream(Pager{
+			New: func() interface{} { return new(T) },
+			Item: func(item interface{}, _ bool) bool {
+				if !yield(*item.(*T), nil) {
+					stopped = true
+					return false
+				}
+				return true
+			},
+		})
+		if err != nil && !stopped {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+// failed returns a sequence of just the error.
+func failed[T any](err error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		yield(zero, err)
+	}
+}
+
+func collect[T any](items iter.Seq2[T, error]) ([]T, error) {
+	var out []T
+	for item, err := range items {
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
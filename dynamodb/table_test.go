@@ -0,0 +1,107 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cleardataeng/aidews/dynamodb/extmocks/github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/golang/mock/gomock"
+)
+
+func TestTable_QuerySeq(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	ddbMock.EXPECT().QueryPagesWithContext(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.QueryInput, f func(*dynamodb.QueryOutput, bool) bool, _ ...request.Option) error {
+			if got := aws.StringValue(input.TableName); got != pagesTable {
+				t.Errorf(`table: want: "%s", got: "%s"`, pagesTable, got)
+			}
+			if f(&dynamodb.QueryOutput{Items: pagesOutput1}, false) {
+				f(&dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{
+					{"slug": {S: aws.String("untitled")}},
+				}}, true)
+			}
+			return nil
+		},
+	)
+
+	table := NewTable[row](&Service{svc: ddbMock}, pagesTable)
+	items, err := table.Query(ctx, Key("slug").BeginsWith("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// each item is separate, so the last does not keep the title before it
+	want := []row{{Slug: "xkcd", Title: "Some guy"}, {Slug: "hijk", Title: "vim"}, {Slug: "untitled"}}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf(`items: want: %+v, got: %+v`, want, items)
+	}
+}
+
+func TestTable_ScanSeqBreak(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	ddbMock.EXPECT().ScanPagesWithContext(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.ScanInput, f func(*dynamodb.ScanOutput, bool) bool, _ ...request.Option) error {
+			if f(&dynamodb.ScanOutput{Items: pagesOutput1}, false) {
+				t.Error("pager continued after the loop broke")
+			}
+			return nil
+		},
+	)
+
+	table := NewTable[row](&Service{svc: ddbMock}, pagesTable)
+	var slugs []string
+	for item, err := range table.ScanSeq(ctx) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		slugs = append(slugs, item.Slug)
+		break
+	}
+	if want := []string{"xkcd"}; !reflect.DeepEqual(slugs, want) {
+		t.Errorf(`slugs: want: %v, got: %v`, want, slugs)
+	}
+}
+
+func TestTable_ScanError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	failed := errors.New("throttled")
+	ddbMock.EXPECT().ScanPagesWithContext(ctx, gomock.Any(), gomock.Any()).Return(failed)
+
+	table := NewTable[row](&Service{svc: ddbMock}, pagesTable)
+	if _, err := table.Scan(ctx); err != failed {
+		t.Errorf(`err: want: "%v", got: "%v"`, failed, err)
+	}
+}
+
+func TestTable_Get(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	ddbMock.EXPECT().GetItemWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, input *dynamodb.GetItemInput, _ ...request.Option) (*dynamodb.GetItemOutput, error) {
+			if got := aws.StringValue(input.Key["slug"].S); got != "xkcd" {
+				t.Errorf(`key: want: "xkcd", got: "%s"`, got)
+			}
+			return &dynamodb.GetItemOutput{Item: pagesOutput1[0]}, nil
+		},
+	)
+
+	table := NewTable[row](&Service{svc: ddbMock}, pagesTable)
+	item, err := table.Get(ctx, map[string]string{"slug": "xkcd"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (row{Slug: "xkcd", Title: "Some guy"}); item != want {
+		t.Errorf(`item: want: %+v, got: %+v`, want, item)
+	}
+}
@@ -0,0 +1,338 @@
+package dynamodb
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// ErrTransactionCanceled is matched, with errors.Is, by TransactionCanceledErrors.
+var ErrTransactionCanceled = errors.New("dynamodb: transaction canceled")
+
+// WriteTx builds a transaction of puts, updates, deletes and condition checks
+// applied all together or not at all by TransactWrite. The first error in
+// adding an operation is returned by TransactWrite.
+//
+// Example:
+//
+//	tx := NewWriteTx().
+//		Put("orders", order, If(Attr("id").NotExists())).
+//		Update("stock", map[string]string{"sku": sku}, Set("count", n-1), If(Attr("count").Equal(n)))
+//	err := svc.TransactWriteWithContext(ctx, tx)
+type WriteTx struct {
+	// Token is the idempotency token of the transaction, random when made by
+	// NewWriteTx. A transaction sent again with the same token within ten
+	// minutes is applied only once.
+	Token string
+
+	items []*dynamodb.TransactWriteItem
+	ops   []string
+	err   error
+}
+
+// NewWriteTx returns a pointer to a new, empty WriteTx with a random Token.
+func NewWriteTx() *WriteTx {
+	return &WriteTx{Token: newToken()}
+}
+
+// Put adds a put of the item to the table, optionally conditional with If.
+func (tx *WriteTx) Put(table string, item interface{}, opts ...Option) *WriteTx {
+	in, err := NewPutItemInput(table, opts...)
+	if err == nil && in.ReturnValues != nil {
+		err = errors.New("put: If is the only transaction option")
+	}
+	if err != nil {
+		return tx.fail(err)
+	}
+	attrs, err := dynamodbattribute.MarshalMap(item)
+	if err != nil {
+		return tx.fail(err)
+	}
+	return tx.add("Put", table, &dynamodb.TransactWriteItem{Put: &dynamodb.Put{
+		ConditionExpression:       in.ConditionExpression,
+		ExpressionAttributeNames:  in.ExpressionAttributeNames,
+		ExpressionAttributeValues: in.ExpressionAttributeValues,
+		Item:                      attrs,
+		TableName:                 in.TableName,
+	}})
+}
+
+// Update adds an update of the item with the key, a struct or map of the key
+// attributes, in the table, optionally conditional with If.
+func (tx *WriteTx) Update(table string, key interface{}, update expression.UpdateBuilder, opts ...Option) *WriteTx {
+	in, err := NewUpdateItemInput(table, key, update, opts...)
+	if err == nil && in.ReturnValues != nil {
+		err = errors.New("update: If is the only transaction option")
+	}
+	if err != nil {
+		return tx.fail(err)
+	}
+	return tx.add("Update", table, &dynamodb.TransactWriteItem{Update: &dynamodb.Update{
+		ConditionExpression:       in.ConditionExpression,
+		ExpressionAttributeNames:  in.ExpressionAttributeNames,
+		ExpressionAttributeValues: in.ExpressionAttributeValues,
+		Key:                       in.Key,
+		TableName:                 in.TableName,
+		UpdateExpression:          in.UpdateExpression,
+	}})
+}
+
+// Delete adds a delete of the item with the key, a struct or map of the key
+// attributes, from the table, optionally conditional with If.
+func (tx *WriteTx) Delete(table string, key interface{}, opts ...Option) *WriteTx {
+	in, err := NewDeleteItemInput(table, key, opts...)
+	if err == nil && in.ReturnValues != nil {
+		err = errors.New("delete: If is the only transaction option")
+	}
+	if err != nil {
+		return tx.fail(err)
+	}
+	return tx.add("Delete", table, &dynamodb.TransactWriteItem{Delete: &dynamodb.Delete{
+		ConditionExpression:       in.ConditionExpression,
+		ExpressionAttributeNames:  in.ExpressionAttributeNames,
+		ExpressionAttributeValues: in.ExpressionAttributeValues,
+		Key:                       in.Key,
+		TableName:                 in.TableName,
+	}})
+}
+
+// ConditionCheck adds a check that the condition holds for the item with the
+// key, a struct or map of the key attributes, in the table, without changing it.
+func (tx *WriteTx) ConditionCheck(table string, key interface{}, cond expression.ConditionBuilder) *WriteTx {
+	in, err := NewDeleteItemInput(table, key, If(cond))
+	if err != nil {
+		return tx.fail(fmt.Errorf("condition check: %w", err))
+	}
+	return tx.add("ConditionCheck", table, &dynamodb.TransactWriteItem{ConditionCheck: &dynamodb.ConditionCheck{
+		ConditionExpression:       in.ConditionExpression,
+		ExpressionAttributeNames:  in.ExpressionAttributeNames,
+		ExpressionAttributeValues: in.ExpressionAttributeValues,
+		Key:                       in.Key,
+		TableName:                 in.TableName,
+	}})
+}
+
+// Len returns the number of operations in the transaction.
+func (tx *WriteTx) Len() int {
+	return len(tx.items)
+}
+
+func (tx *WriteTx) add(op, table string, item *dynamodb.TransactWriteItem) *WriteTx {
+	if tx.err == nil {
+		tx.items = append(tx.items, item)
+		tx.ops = append(tx.ops, op+" on "+table)
+	}
+	return tx
+}
+
+func (tx *WriteTx) fail(err error) *WriteTx {
+	if tx.err == nil {
+		tx.err = fmt.Errorf("operation %d: %w", len(tx.items), err)
+	}
+	return tx
+}
+
+// TransactWrite applies the operations of the transaction all together or not
+// at all.
+// See TransactWriteWithContext.
+func (svc *Service) TransactWrite(tx *WriteTx) error {
+	return svc.TransactWriteWithContext(context.TODO(), tx)
+}
+
+// TransactWriteWithContext applies the operations of the transaction all
+// together or not at all. If DynamoDB cancels the transaction, a
+// TransactionCanceledError says which operations failed and why.
+func (svc *Service) TransactWriteWithContext(ctx context.Context, tx *WriteTx) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	if len(tx.items) == 0 {
+		return errors.New("TransactWriteItems: no operations")
+	}
+	in := &dynamodb.TransactWriteItemsInput{TransactItems: tx.items}
+	if tx.Token != "" {
+		in.ClientRequestToken = aws.String(tx.Token)
+	}
+	_, err := svc.svc.TransactWriteItemsWithContext(ctx, in)
+	return txError("TransactWriteItems", tx.ops, err)
+}
+
+// GetTx builds a transaction of gets read together, as of one point in time,
+// by TransactGet.
+type GetTx struct {
+	items []*dynamodb.TransactGetItem
+	outs  []interface{}
+	ops   []string
+	err   error
+}
+
+// NewGetTx returns a pointer to a new, empty GetTx.
+func NewGetTx() *GetTx {
+	return &GetTx{}
+}
+
+// Get adds a get of the item with the key, a struct or map of the key
+// attributes, from the table, unmarshalled into out. Only the projected
+// top-level attributes are got, or all if there are none.
+func (tx *GetTx) Get(table string, key, out interface{}, projected ...string) *GetTx {
+	k, err := dynamodbattribute.MarshalMap(key)
+	if err != nil {
+		if tx.err == nil {
+			tx.err = fmt.Errorf("operation %d: %w", len(tx.items), err)
+		}
+		return tx
+	}
+	get := &dynamodb.Get{Key: k, TableName: aws.String(table)}
+	get.ProjectionExpression, get.ExpressionAttributeNames = projection(projected)
+	tx.items = append(tx.items, &dynamodb.TransactGetItem{Get: get})
+	tx.outs = append(tx.outs, out)
+	tx.ops = append(tx.ops, "Get on "+table)
+	return tx
+}
+
+// Len returns the number of operations in the transaction.
+func (tx *GetTx) Len() int {
+	return len(tx.items)
+}
+
+// TransactGet gets the items of the transaction, unmarshalling each into its
+// out.
+// See TransactGetWithContext.
+func (svc *Service) TransactGet(tx *GetTx) error {
+	return svc.TransactGetWithContext(context.TODO(), tx)
+}
+
+// TransactGetWithContext gets the items of the transaction, unmarshalling each
+// into its out. The outs of missing items are left unchanged, and an error
+// matching ErrNotFound naming the first of them is returned.
+func (svc *Service) TransactGetWithContext(ctx context.Context, tx *GetTx) error {
+	if tx.err != nil {
+		return tx.err
+	}
+	if len(tx.items) == 0 {
+		return errors.New("TransactGetItems: no operations")
+	}
+	res, err := svc.svc.TransactGetItemsWithContext(ctx, &dynamodb.TransactGetItemsInput{TransactItems: tx.items})
+	if err != nil {
+		return txError("TransactGetItems", tx.ops, err)
+	}
+	var missing error
+	for i, out := range tx.outs {
+		if i >= len(res.Responses) || len(res.Responses[i].Item) == 0 {
+			if missing == nil {
+				missing = fmt.Errorf("TransactGetItems: operation %d (%s): %w", i, tx.ops[i], ErrNotFound)
+			}
+			continue
+		}
+		if err := dynamodbattribute.UnmarshalMap(res.Responses[i].Item, out); err != nil {
+			return err
+		}
+	}
+	return missing
+}
+
+// CancellationReason is why one operation of a canceled transaction failed.
+type CancellationReason struct {
+	// Index is the position of the operation in the transaction.
+	Index int
+
+	// Op describes the operation, e.g. "Update on stock".
+	Op string
+
+	// Code is e.g. "ConditionalCheckFailed", "TransactionConflict" or
+	// "ThrottlingError".
+	Code    string
+	Message string
+}
+
+// TransactionCanceledError is the failure of a transaction DynamoDB canceled.
+type TransactionCanceledError struct {
+	Op string
+
+	// Reasons are those of the operations that failed, in order.
+	Reasons []CancellationReason
+	Err     error
+}
+
+func (e *TransactionCanceledError) Error() string {
+	if len(e.Reasons) == 0 {
+		return fmt.Sprintf("%s: transaction canceled: %v", e.Op, e.Err)
+	}
+	reasons := make([]string, len(e.Reasons))
+	for i, r := range e.Reasons {
+		reasons[i] = fmt.Sprintf("operation %d (%s): %s", r.Index, r.Op, r.Code)
+		if r.Message != "" {
+			reasons[i] += ": " + r.Message
+		}
+	}
+	return fmt.Sprintf("%s: transaction canceled: %s", e.Op, strings.Join(reasons, "; "))
+}
+
+// Is reports whether target is ErrTransactionCanceled, or ErrConditionFailed
+// or ErrThrottled if an operation failed for that reason.
+func (e *TransactionCanceledError) Is(target error) bool {
+	switch target {
+	case ErrTransactionCanceled:
+		return true
+	case ErrConditionFailed:
+		return e.hasReason("ConditionalCheckFailed")
+	case ErrThrottled:
+		return e.hasReason("ThrottlingError", "ProvisionedThroughputExceeded")
+	}
+	return false
+}
+
+func (e *TransactionCanceledError) Unwrap() error {
+	return e.Err
+}
+
+func (e *TransactionCanceledError) hasReason(codes ...string) bool {
+	for _, r := range e.Reasons {
+		for _, code := range codes {
+			if r.Code == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// txError returns err from the transaction of the described operations as a
+// TransactionCanceledError if it is one, and otherwise as wrapError does.
+func txError(op string, ops []string, err error) error {
+	var canceled *dynamodb.TransactionCanceledException
+	if !errors.As(err, &canceled) {
+		return wrapError(op, err)
+	}
+	txErr := &TransactionCanceledError{Op: op, Err: err}
+	for i, r := range canceled.CancellationReasons {
+		code := aws.StringValue(r.Code)
+		if code == "" || code == "None" {
+			continue
+		}
+		reason := CancellationReason{Index: i, Code: code, Message: aws.StringValue(r.Message)}
+		if i < len(ops) {
+			reason.Op = ops[i]
+		}
+		txErr.Reasons = append(txErr.Reasons, reason)
+	}
+	return txErr
+}
+
+// newToken returns a random idempotency token in the form of a UUID.
+func newToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "" // the SDK makes one
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,111 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cleardataeng/aidews/dynamodb/extmocks/github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/golang/mock/gomock"
+)
+
+func TestService_TransactWrite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	tx := NewWriteTx().
+		Put(pagesTable, row{Slug: "xkcd", Title: "xkcd"}, If(Attr("Slug").NotExists())).
+		Update("stock", map[string]string{"sku": "book"}, Set("count", 4), If(Attr("count").Equal(5))).
+		Delete("drafts", map[string]string{"slug": "xkcd"}).
+		ConditionCheck("authors", map[string]string{"name": "randall"}, Attr("name").Exists())
+	ddbMock.EXPECT().TransactWriteItemsWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *dynamodb.TransactWriteItemsInput, _ ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+			if got := aws.StringValue(in.ClientRequestToken); got == "" || got != tx.Token {
+				t.Errorf(`token: want: "%s", got: "%s"`, tx.Token, got)
+			}
+			if len(in.TransactItems) != 4 {
+				t.Fatalf(`items: want: 4, got: %d`, len(in.TransactItems))
+			}
+			put := in.TransactItems[0].Put
+			if put == nil || aws.StringValue(put.Item["Title"].S) != "xkcd" || put.ConditionExpression == nil {
+				t.Errorf(`put: want a conditional put of the item, got: %v`, put)
+			}
+			update := in.TransactItems[1].Update
+			if update == nil || update.UpdateExpression == nil || aws.StringValue(update.Key["sku"].S) != "book" {
+				t.Errorf(`update: want an update of "book", got: %v`, update)
+			}
+			if in.TransactItems[2].Delete == nil || in.TransactItems[3].ConditionCheck == nil {
+				t.Errorf(`want a delete and a condition check, got: %v`, in.TransactItems[2:])
+			}
+			return nil, &dynamodb.TransactionCanceledException{
+				Message_: aws.String("Transaction cancelled"),
+				CancellationReasons: []*dynamodb.CancellationReason{
+					{Code: aws.String("None")},
+					{Code: aws.String("ConditionalCheckFailed"), Message: aws.String("The conditional request failed")},
+					{Code: aws.String("None")},
+					{Code: aws.String("None")},
+				},
+			}
+		},
+	)
+
+	svc := Service{svc: ddbMock}
+	err := svc.TransactWriteWithContext(ctx, tx)
+	var txErr *TransactionCanceledError
+	if !errors.As(err, &txErr) {
+		t.Fatalf(`err: want: TransactionCanceledError, got: %v`, err)
+	}
+	want := CancellationReason{Index: 1, Op: "Update on stock", Code: "ConditionalCheckFailed", Message: "The conditional request failed"}
+	if len(txErr.Reasons) != 1 || txErr.Reasons[0] != want {
+		t.Errorf(`reasons: want: %v, got: %v`, want, txErr.Reasons)
+	}
+	if !errors.Is(err, ErrTransactionCanceled) || !errors.Is(err, ErrConditionFailed) || errors.Is(err, ErrThrottled) {
+		t.Errorf(`err: want to match ErrTransactionCanceled and ErrConditionFailed only, got: %v`, err)
+	}
+}
+
+func TestWriteTx_invalid(t *testing.T) {
+	tx := NewWriteTx().
+		Delete(pagesTable, map[string]string{"slug": "xkcd"}).
+		Put(pagesTable, row{}, Limit(1)).
+		Delete(pagesTable, map[string]string{"slug": "smbc"})
+	svc := Service{}
+	if err := svc.TransactWriteWithContext(ctx, tx); err == nil {
+		t.Error("expected an error for an invalid option")
+	}
+	if tx.Len() != 1 {
+		t.Errorf(`len: want: 1, got: %d`, tx.Len())
+	}
+}
+
+func TestService_TransactGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	ddbMock.EXPECT().TransactGetItemsWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *dynamodb.TransactGetItemsInput, _ ...request.Option) (*dynamodb.TransactGetItemsOutput, error) {
+			if got := aws.StringValue(in.TransactItems[0].Get.ProjectionExpression); got != "#p0" {
+				t.Errorf(`projection: want: "#p0", got: "%s"`, got)
+			}
+			return &dynamodb.TransactGetItemsOutput{Responses: []*dynamodb.ItemResponse{
+				{Item: map[string]*dynamodb.AttributeValue{"Title": {S: aws.String("xkcd")}}},
+				{},
+			}}, nil
+		},
+	)
+
+	svc := Service{svc: ddbMock}
+	var found, missing row
+	err := svc.TransactGetWithContext(ctx, NewGetTx().
+		Get(pagesTable, map[string]string{"slug": "xkcd"}, &found, "Title").
+		Get(pagesTable, map[string]string{"slug": "gone"}, &missing))
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf(`err: want: ErrNotFound, got: %v`, err)
+	}
+	if found.Title != "xkcd" {
+		t.Errorf(`title: want: "xkcd", got: "%s"`, found.Title)
+	}
+}
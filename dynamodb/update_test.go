@@ -0,0 +1,85 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cleardataeng/aidews/dynamodb/extmocks/github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/golang/mock/gomock"
+)
+
+type partialRow struct {
+	Slug  string `dynamodbav:"slug"`
+	Title string `dynamodbav:"title,omitempty"`
+	Views int    `dynamodbav:"views,omitempty"`
+}
+
+func TestService_UpdateItemPartial(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	ddbMock.EXPECT().UpdateItemWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *dynamodb.UpdateItemInput, _ ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+			// views is empty and the key is excluded, so only the title is set
+			if got := aws.StringValue(in.UpdateExpression); got != "SET #0 = :0\n" {
+				t.Errorf(`update: want: "SET #0 = :0\n", got: %q`, got)
+			}
+			if got := aws.StringValue(in.ExpressionAttributeNames["#0"]); got != "title" {
+				t.Errorf(`#0: want: "title", got: "%s"`, got)
+			}
+			if got := aws.StringValue(in.ReturnValues); got != dynamodb.ReturnValueAllNew {
+				t.Errorf(`return values: want: "%s", got: "%s"`, dynamodb.ReturnValueAllNew, got)
+			}
+			return &dynamodb.UpdateItemOutput{Attributes: map[string]*dynamodb.AttributeValue{
+				"slug":  {S: aws.String("wasd")},
+				"title": {S: aws.String("gaming")},
+				"views": {N: aws.String("3")},
+			}}, nil
+		},
+	)
+
+	update, err := SetAll(partialRow{Slug: "wasd", Title: "gaming"}, "slug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	in, err := NewUpdateItemInput(pagesTable, map[string]string{"slug": "wasd"}, update, ReturnValues(dynamodb.ReturnValueAllNew))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := Service{svc: ddbMock}
+	var got partialRow
+	if err := svc.UpdateItemWithContext(ctx, in, &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := (partialRow{Slug: "wasd", Title: "gaming", Views: 3}); got != want {
+		t.Errorf(`item: want: %+v, got: %+v`, want, got)
+	}
+}
+
+func TestService_DeleteItemConditionFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	ddbMock.EXPECT().DeleteItemWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *dynamodb.DeleteItemInput, _ ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+			if got := aws.StringValue(in.ConditionExpression); got != "#0 = :0" {
+				t.Errorf(`condition: want: "#0 = :0", got: "%s"`, got)
+			}
+			return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+		},
+	)
+
+	in, err := NewDeleteItemInput(pagesTable, map[string]string{"slug": "wasd"}, If(Attr("title").Equal("gaming")), ReturnValues(dynamodb.ReturnValueAllOld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := Service{svc: ddbMock}
+	if err := svc.DeleteItemWithContext(ctx, in, &row{}); !errors.Is(err, ErrConditionFailed) {
+		t.Errorf(`err: want: ErrConditionFailed, got: %v`, err)
+	}
+}
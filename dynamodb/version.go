@@ -0,0 +1,227 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// ErrVersionConflict is matched, with errors.Is, by VersionConflictErrors.
+var ErrVersionConflict = errors.New("dynamodb: version conflict")
+
+// VersionConflictError is the failure of a versioned write because the
+// stored item is not at the expected version: it was changed, or created,
+// since it was read.
+type VersionConflictError struct {
+	Op       string
+	Expected int64
+	Err      error
+}
+
+func (e *VersionConflictError) Error() string {
+	if e.Expected == 0 {
+		return fmt.Sprintf("%s: version conflict: item exists", e.Op)
+	}
+	return fmt.Sprintf("%s: version conflict: item is not at version %d", e.Op, e.Expected)
+}
+
+// Is reports whether target is ErrVersionConflict.
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+func (e *VersionConflictError) Unwrap() error {
+	return e.Err
+}
+
+// Put puts the item, a struct, a pointer to a struct or a map, in the table,
+// optionally conditional with If.
+// See PutWithContext.
+func (svc *Service) Put(table string, item interface{}, opts ...Option) error {
+	return svc.PutWithContext(context.TODO(), table, item, opts...)
+}
+
+// PutWithContext puts the item, a struct, a pointer to a struct or a map, in
+// the table, optionally conditional with If.
+//
+// If the struct has an integer field tagged `aidews:"version"`, item must be
+// a pointer and the put is locked optimistically: it succeeds only if the
+// stored item is at the field's version, or there is none when the version
+// is zero, and stores, and sets the field to, the next version. Otherwise it
+// fails with a VersionConflictError. Conditions given with If must hold as
+// well; a failure of either is reported as a conflict.
+//
+// Example:
+//
+//	type Page struct {
+//		Slug    string `dynamodbav:"slug"`
+//		Version int64  `dynamodbav:"version" aidews:"version"`
+//	}
+func (svc *Service) PutWithContext(ctx context.Context, table string, item interface{}, opts ...Option) error {
+	v, err := versionOf(item)
+	if err != nil {
+		return err
+	}
+	attrs, err := dynamodbattribute.MarshalMap(item)
+	if err != nil {
+		return err
+	}
+	if v != nil {
+		if attrs[v.attr], err = dynamodbattribute.Marshal(v.expected + 1); err != nil {
+			return err
+		}
+		opts = append(opts, andIf(v.condition()))
+	}
+	in, err := NewPutItemInput(table, opts...)
+	if err != nil {
+		return err
+	}
+	if in.ReturnValues != nil {
+		return errors.New("put: If is the only Put option")
+	}
+	in.Item = attrs
+	if _, err := svc.svc.PutItemWithContext(ctx, in); err != nil {
+		return v.conflict("PutItem", wrapError("PutItem", err))
+	}
+	v.advance()
+	return nil
+}
+
+// Update sets the attributes of item, a partial struct, a pointer to one or a
+// map, in the item with the key, a struct or map of the key attributes, in the
+// table.
+// See UpdateWithContext.
+func (svc *Service) Update(table string, key, item interface{}, opts ...Option) error {
+	return svc.UpdateWithContext(context.TODO(), table, key, item, opts...)
+}
+
+// UpdateWithContext sets the attributes of item, a partial struct, a pointer
+// to one or a map, in the item with the key, a struct or map of the key
+// attributes, in the table. Empty struct fields tagged omitempty are left
+// unchanged. The update is optionally conditional with If, and, with
+// ReturnValues, the chosen attributes are unmarshalled into item.
+//
+// As with Put, an item with a version field must be a pointer and the update
+// is locked optimistically.
+func (svc *Service) UpdateWithContext(ctx context.Context, table string, key, item interface{}, opts ...Option) error {
+	v, err := versionOf(item)
+	if err != nil {
+		return err
+	}
+	k, err := dynamodbattribute.MarshalMap(key)
+	if err != nil {
+		return err
+	}
+	attrs, err := dynamodbattribute.MarshalMap(item)
+	if err != nil {
+		return err
+	}
+	except := make([]string, 0, len(k)+1)
+	for name := range k {
+		except = append(except, name)
+	}
+	if v != nil {
+		except = append(except, v.attr)
+	}
+	update, n := setAll(attrs, except)
+	if v != nil {
+		update = update.Set(expression.Name(v.attr), expression.Value(v.expected+1))
+		opts = append(opts, andIf(v.condition()))
+	} else if n == 0 {
+		return errors.New("update: no attributes to set")
+	}
+	in, err := NewUpdateItemInput(table, key, update, opts...)
+	if err != nil {
+		return err
+	}
+	var out interface{}
+	if in.ReturnValues != nil {
+		out = item
+	}
+	if err := svc.UpdateItemWithContext(ctx, in, out); err != nil {
+		return v.conflict("UpdateItem", err)
+	}
+	v.advance()
+	return nil
+}
+
+// version is the version field of an item.
+type version struct {
+	attr     string
+	field    reflect.Value
+	expected int64
+}
+
+// versionOf returns the version field of item, or nil if it has none.
+func versionOf(item interface{}) (*version, error) {
+	rv := reflect.ValueOf(item)
+	ptr := rv.Kind() == reflect.Ptr
+	if ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.Tag.Get("aidews") != "version" {
+			continue
+		}
+		if !f.IsExported() {
+			return nil, fmt.Errorf("version field %s of %T is not exported", f.Name, item)
+		}
+		if !ptr {
+			return nil, fmt.Errorf("versioned item %T must be a pointer", item)
+		}
+		v := &version{attr: f.Name, field: rv.Field(i)}
+		if name := strings.Split(f.Tag.Get("dynamodbav"), ",")[0]; name != "" {
+			v.attr = name
+		}
+		switch v.field.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v.expected = v.field.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v.expected = int64(v.field.Uint())
+		default:
+			return nil, fmt.Errorf("version field %s of %T is not an integer", f.Name, item)
+		}
+		return v, nil
+	}
+	return nil, nil
+}
+
+// condition returns the condition that the stored item is at the expected
+// version, or that there is none.
+func (v *version) condition() expression.ConditionBuilder {
+	if v.expected == 0 {
+		return expression.Name(v.attr).AttributeNotExists()
+	}
+	return expression.Name(v.attr).Equal(expression.Value(v.expected))
+}
+
+// conflict returns err as a VersionConflictError if it is a failed
+// condition of a versioned write, and otherwise unchanged.
+func (v *version) conflict(op string, err error) error {
+	if v == nil || !errors.Is(err, ErrConditionFailed) {
+		return err
+	}
+	return &VersionConflictError{Op: op, Expected: v.expected, Err: err}
+}
+
+// advance sets the field to the next version after a write.
+func (v *version) advance() {
+	if v == nil {
+		return
+	}
+	if v.field.CanInt() {
+		v.field.SetInt(v.expected + 1)
+	} else {
+		v.field.SetUint(uint64(v.expected + 1))
+	}
+}
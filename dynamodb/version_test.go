@@ -0,0 +1,136 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cleardataeng/aidews/dynamodb/extmocks/github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/golang/mock/gomock"
+)
+
+type versionedRow struct {
+	Slug    string `dynamodbav:"slug"`
+	Title   string `dynamodbav:"title,omitempty"`
+	Version int64  `dynamodbav:"version" aidews:"version"`
+}
+
+func TestService_PutVersioned(t *testing.T) {
+	tests := map[string]struct {
+		version   int64
+		condition string
+		values    int
+	}{
+		"new":      {0, "attribute_not_exists (#0)", 0},
+		"existing": {3, "#0 = :0", 1},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+			ddbMock.EXPECT().PutItemWithContext(ctx, gomock.Any()).DoAndReturn(
+				func(_ context.Context, in *dynamodb.PutItemInput, _ ...request.Option) (*dynamodb.PutItemOutput, error) {
+					if got := aws.StringValue(in.ConditionExpression); got != tt.condition {
+						t.Errorf(`condition: want: "%s", got: "%s"`, tt.condition, got)
+					}
+					if got := aws.StringValue(in.ExpressionAttributeNames["#0"]); got != "version" {
+						t.Errorf(`#0: want: "version", got: "%s"`, got)
+					}
+					if len(in.ExpressionAttributeValues) != tt.values {
+						t.Errorf(`values: want: %d, got: %d`, tt.values, len(in.ExpressionAttributeValues))
+					}
+					if got, want := aws.StringValue(in.Item["version"].N), strconv.FormatInt(tt.version+1, 10); got != want {
+						t.Errorf(`stored version: want: "%s", got: "%s"`, want, got)
+					}
+					return &dynamodb.PutItemOutput{}, nil
+				},
+			)
+
+			svc := Service{svc: ddbMock}
+			item := &versionedRow{Slug: "xkcd", Version: tt.version}
+			if err := svc.PutWithContext(ctx, pagesTable, item); err != nil {
+				t.Fatal(err)
+			}
+			if item.Version != tt.version+1 {
+				t.Errorf(`version: want: %d, got: %d`, tt.version+1, item.Version)
+			}
+		})
+	}
+}
+
+func TestService_UpdateVersionConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	ddbMock.EXPECT().UpdateItemWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *dynamodb.UpdateItemInput, _ ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+			if got, want := aws.StringValue(in.ConditionExpression), "(attribute_exists (#0)) AND (#1 = :0)"; got != want {
+				t.Errorf(`condition: want: "%s", got: "%s"`, want, got)
+			}
+			if got, want := aws.StringValue(in.UpdateExpression), "SET #2 = :1, #1 = :2\n"; got != want {
+				t.Errorf(`update: want: %q, got: %q`, want, got)
+			}
+			if _, ok := in.Key["slug"]; !ok {
+				t.Errorf(`key: want slug, got: %v`, in.Key)
+			}
+			return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "", nil)
+		},
+	)
+
+	svc := Service{svc: ddbMock}
+	item := &versionedRow{Title: "xkcd", Version: 7}
+	err := svc.UpdateWithContext(ctx, pagesTable, map[string]string{"slug": "xkcd"}, item, If(Attr("slug").Exists()))
+	var conflict *VersionConflictError
+	if !errors.As(err, &conflict) || conflict.Expected != 7 {
+		t.Fatalf(`err: want: VersionConflictError at 7, got: %v`, err)
+	}
+	if !errors.Is(err, ErrVersionConflict) || !errors.Is(err, ErrConditionFailed) {
+		t.Errorf(`err: want to match ErrVersionConflict and ErrConditionFailed, got: %v`, err)
+	}
+	if item.Version != 7 {
+		t.Errorf(`version: want unchanged, got: %d`, item.Version)
+	}
+}
+
+func TestService_PutVersionedValue(t *testing.T) {
+	svc := Service{}
+	if err := svc.PutWithContext(ctx, pagesTable, versionedRow{Slug: "xkcd"}); err == nil {
+		t.Error("expected an error for a versioned item that is not a pointer")
+	}
+}
+
+func TestService_PutVersionUnexported(t *testing.T) {
+	type unexported struct {
+		Slug    string `dynamodbav:"slug"`
+		version int64  `aidews:"version"`
+	}
+	svc := Service{}
+	if err := svc.PutWithContext(ctx, pagesTable, &unexported{Slug: "xkcd"}); err == nil {
+		t.Error("expected an error for an unexported version field")
+	}
+}
+
+func TestTable_PutVersioned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ddbMock := mock_dynamodbiface.NewMockDynamoDBAPI(ctrl)
+	ddbMock.EXPECT().PutItemWithContext(ctx, gomock.Any()).Return(&dynamodb.PutItemOutput{}, nil).Times(2)
+
+	svc := &Service{svc: ddbMock}
+	if err := NewTable[row](svc, pagesTable).Put(ctx, row{Slug: "xkcd"}); err != nil {
+		t.Fatal(err)
+	}
+	item := &versionedRow{Slug: "xkcd", Version: 2}
+	if err := NewTable[*versionedRow](svc, pagesTable).Put(ctx, item); err != nil {
+		t.Fatal(err)
+	}
+	if item.Version != 3 {
+		t.Errorf(`version: want: 3, got: %d`, item.Version)
+	}
+}
@@ -1,10 +1,11 @@
 module github.com/cleardataeng/aidews
 
-go 1.17
+go 1.23
 
 require (
 	github.com/aws/aws-sdk-go v1.44.191
 	github.com/golang/mock v1.6.0
+	github.com/klauspost/compress v1.18.0
 	github.com/sirupsen/logrus v1.9.0
 	gopkg.in/yaml.v2 v2.4.0
 )
@@ -1,13 +1,15 @@
 package s3
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
 	"io"
 	"io/ioutil"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/cleardataeng/aidews"
 )
 
@@ -16,19 +18,31 @@ type Service struct {
 	// acl is the default ACL for bucket objects.
 	acl *string
 
+	// codec marshals content for PutMarshal and is the fallback for ReadUnmarshal.
+	codec Codec
+
 	// name of the bucket.
 	name string
 
 	// sse is the default server side encryption setting.
-	sse *string
+	sse *Encryption
+
+	// storageClass is the default storage class for bucket objects.
+	storageClass *string
 
 	// svc is the S3API client.
 	svc s3iface.S3API
+
+	// tags are the default tags for bucket objects.
+	tags map[string]string
 }
 
 // New returns a pointer to a new Service.
 // ACL is bucket-owner-full-control by default, but can be changed with SetACL.
-// SSE is AES256 by default, but can be changed with SetSSE.
+// SSE is AES256 by default, but can be changed with SetSSE or SetEncryption.
+// Codec is JSON by default, but can be changed with SetCodec.
+// Objects have no tags and the S3 storage class by default, but these can be
+// changed with SetDefaultTags and SetStorageClass.
 func New(name string, region, roleARN *string) *Service {
 	return newWithSvc(name, s3.New(aidews.Session(region, roleARN)))
 }
@@ -40,49 +54,116 @@ func NewWithConfig(name string, cfg aws.Config, roleARN *string) *Service {
 
 func newWithSvc(name string, svc s3iface.S3API) *Service {
 	return &Service{
-		acl:  aws.String("bucket-owner-full-control"),
-		name: name,
-		sse:  aws.String("AES256"),
-		svc:  svc,
+		acl:   aws.String("bucket-owner-full-control"),
+		codec: JSON,
+		name:  name,
+		sse:   &Encryption{Algorithm: SSEAES256},
+		svc:   svc,
 	}
 }
 
 // Put puts the content to the bucket at the key.
 func (svc *Service) Put(key string, content io.Reader) (*s3.PutObjectOutput, error) {
+	res, err := svc.svc.PutObject(svc.putInput(key, content))
+	return res, svc.lockError(context.TODO(), err, svc.name, key, "")
+}
+
+// PutMarshal marshals v with the Service codec and puts it to the bucket at the key.
+// The object is stored with the Content-Type and Content-Encoding of the codec.
+func (svc *Service) PutMarshal(key string, v interface{}) (*s3.PutObjectOutput, error) {
+	data, err := svc.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	in := svc.putInput(key, bytes.NewReader(data))
+	in.ContentType = aws.String(svc.codec.ContentType())
+	if enc := svc.codec.ContentEncoding(); enc != "" {
+		in.ContentEncoding = aws.String(enc)
+	}
+	res, err := svc.svc.PutObject(in)
+	return res, svc.lockError(context.TODO(), err, svc.name, key, "")
+}
+
+func (svc *Service) putInput(key string, content io.Reader) *s3.PutObjectInput {
 	in := &s3.PutObjectInput{
-		ACL:                  svc.acl,
-		Body:                 aws.ReadSeekCloser(content),
-		Bucket:               aws.String(svc.name),
-		Key:                  aws.String(key),
-		ServerSideEncryption: svc.sse,
+		ACL:          svc.acl,
+		Body:         aws.ReadSeekCloser(content),
+		Bucket:       aws.String(svc.name),
+		Key:          aws.String(key),
+		StorageClass: svc.storageClass,
+		Tagging:      svc.tagging(nil),
+	}
+	svc.sse.applyPut(in)
+	return in
+}
+
+// Upload puts the content to the bucket at the key.
+// See UploadWithContext.
+func (svc *Service) Upload(key string, content io.Reader) (*s3manager.UploadOutput, error) {
+	return svc.UploadWithContext(context.TODO(), key, content)
+}
+
+// UploadWithContext puts the content to the bucket at the key, streaming large
+// content as a multipart upload rather than reading it all into memory.
+func (svc *Service) UploadWithContext(ctx context.Context, key string, content io.Reader) (*s3manager.UploadOutput, error) {
+	res, err := s3manager.NewUploaderWithClient(svc.svc).UploadWithContext(ctx, svc.uploadInput(key, content))
+	return res, svc.lockError(ctx, err, svc.name, key, "")
+}
+
+func (svc *Service) uploadInput(key string, content io.Reader) *s3manager.UploadInput {
+	in := &s3manager.UploadInput{
+		ACL:          svc.acl,
+		Body:         content,
+		Bucket:       aws.String(svc.name),
+		Key:          aws.String(key),
+		StorageClass: svc.storageClass,
+		Tagging:      svc.tagging(nil),
 	}
-	return svc.svc.PutObject(in)
+	svc.sse.applyUpload(in)
+	return in
 }
 
 // Read gets the object from the bucket at the key.
 func (svc *Service) Read(key string) (*io.ReadCloser, error) {
+	res, err := svc.getObject(context.TODO(), key)
+	if err != nil {
+		return nil, err
+	}
+	return &res.Body, nil
+}
+
+func (svc *Service) getObject(ctx context.Context, key string) (*s3.GetObjectOutput, error) {
+	return svc.getObjectVersion(ctx, key, "")
+}
+
+// getObjectVersion gets the version of the object at the key, or the current
+// version when versionID is empty.
+func (svc *Service) getObjectVersion(ctx context.Context, key, versionID string) (*s3.GetObjectOutput, error) {
 	in := &s3.GetObjectInput{
 		Bucket: aws.String(svc.name),
 	}
 	in.SetKey(key)
-	res, err := svc.svc.GetObject(in)
-	if err != nil {
-		return nil, err
+	if versionID != "" {
+		in.SetVersionId(versionID)
 	}
-	return &res.Body, nil
+	svc.sse.applyGet(in)
+	return svc.svc.GetObjectWithContext(ctx, in)
 }
 
 // ReadUnmarshal gets the object from the bucket at the key and unmarshals into out.
+// The codec registered for the object's Content-Type is used, falling back to
+// the Service codec, and the content is decoded according to its Content-Encoding.
 func (svc *Service) ReadUnmarshal(key string, out interface{}) error {
-	obj, err := svc.Read(key)
+	res, err := svc.getObject(context.TODO(), key)
 	if err != nil {
 		return err
 	}
-	data, err := ioutil.ReadAll(*obj)
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(data, out)
+	return unmarshalContent(data, aws.StringValue(res.ContentType), aws.StringValue(res.ContentEncoding), svc.codec, out)
 }
 
 // SetACL sets the ACL with which the objects will be stored.
@@ -90,26 +171,45 @@ func (svc *Service) SetACL(v *string) {
 	svc.acl = v
 }
 
+// SetCodec sets the codec with which PutMarshal stores objects.
+func (svc *Service) SetCodec(c Codec) {
+	svc.codec = c
+}
+
 // SetSSE sets the server side encryption string for the bucket.
+// A nil string disables server side encryption. Use SetEncryption for KMS keys
+// and customer provided keys.
 func (svc *Service) SetSSE(v *string) {
-	svc.sse = v
+	if v == nil {
+		svc.sse = nil
+		return
+	}
+	svc.sse = &Encryption{Algorithm: *v}
+}
+
+// SetEncryption sets the encryption of objects written by the Service, and the
+// customer key with which objects are read. A nil Encryption disables server
+// side encryption.
+func (svc *Service) SetEncryption(e *Encryption) {
+	svc.sse = e
 }
 
 // ListObjectsKeysV2Pages will list the bucket keys page-wise.
+// Only the first page matching params is listed; continue with the returned
+// keys as StartAfter until the last page is reported. To walk every page, see
+// ListWithContext.
 func (svc *Service) ListObjectsKeysV2Pages(params *s3.ListObjectsV2Input) ([]string, bool, error) {
 
 	var keys []string
 	var isLast bool
 	listObjectsOutputFn := func(page *s3.ListObjectsV2Output, lastPage bool) bool {
-		if page.Contents != nil {
-			for _, obj := range page.Contents {
-				if obj.Key == nil {
-					continue
-				}
-				keys = append(keys, *obj.Key)
+		for _, obj := range page.Contents {
+			if obj.Key == nil {
+				continue
 			}
-			isLast = lastPage
+			keys = append(keys, *obj.Key)
 		}
+		isLast = lastPage
 		return false
 	}
 
@@ -0,0 +1,101 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cleardataeng/aidews/s3/extmocks/github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+)
+
+//go:generate mockgen -destination=extmocks/github.com/aws/aws-sdk-go/service/s3/mock.go github.com/aws/aws-sdk-go/service/s3/s3iface S3API
+
+type doc struct {
+	Slug  string `json:"slug" yaml:"slug"`
+	Title string `json:"title" yaml:"title"`
+}
+
+var testBucket = "movement-keys"
+
+func TestService_PutMarshalReadUnmarshal(t *testing.T) {
+	tests := []struct {
+		name         string
+		codec        Codec
+		wantType     string
+		wantEncoding *string
+	}{
+		{"json", JSON, "application/json", nil},
+		{"yaml", YAML, "application/x-yaml", nil},
+		{"gzip json", GzipJSON, "application/json", aws.String("gzip")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			s3Mock := mock_s3iface.NewMockS3API(ctrl)
+
+			var stored *s3.PutObjectInput
+			var body []byte
+			s3Mock.EXPECT().PutObject(gomock.Any()).DoAndReturn(
+				func(in *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+					stored = in
+					body, _ = ioutil.ReadAll(in.Body)
+					return &s3.PutObjectOutput{}, nil
+				},
+			)
+			s3Mock.EXPECT().GetObjectWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, in *s3.GetObjectInput, _ ...request.Option) (*s3.GetObjectOutput, error) {
+					return &s3.GetObjectOutput{
+						Body:            ioutil.NopCloser(bytes.NewReader(body)),
+						ContentEncoding: stored.ContentEncoding,
+						ContentType:     stored.ContentType,
+					}, nil
+				},
+			)
+
+			svc := newWithSvc(testBucket, s3Mock)
+			svc.SetCodec(tt.codec)
+			want := doc{Slug: "xkcd", Title: "Some guy"}
+			if _, err := svc.PutMarshal("doc", want); err != nil {
+				t.Fatal(err)
+			}
+			if got := aws.StringValue(stored.ContentType); got != tt.wantType {
+				t.Errorf(`content type: want: "%s", got: "%s"`, tt.wantType, got)
+			}
+			if !reflect.DeepEqual(stored.ContentEncoding, tt.wantEncoding) {
+				t.Errorf(`content encoding: want: %v, got: %v`, tt.wantEncoding, stored.ContentEncoding)
+			}
+
+			// read back with the default codec to show the object headers drive decoding
+			svc.SetCodec(JSON)
+			var got doc
+			if err := svc.ReadUnmarshal("doc", &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf(`doc: want: %+v, got: %+v`, want, got)
+			}
+		})
+	}
+}
+
+func TestService_ReadUnmarshalUnknownEncoding(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	s3Mock.EXPECT().GetObjectWithContext(gomock.Any(), gomock.Any()).Return(&s3.GetObjectOutput{
+		Body:            ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+		ContentEncoding: aws.String("br"),
+	}, nil)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	if err := svc.ReadUnmarshal("doc", &doc{}); err == nil {
+		t.Error("expected error for unsupported content encoding")
+	}
+}
@@ -0,0 +1,734 @@
+package s3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cleardataeng/aidews/policy"
+)
+
+// LifecycleRule is a rule of the bucket lifecycle configuration, applying to
+// the objects under Prefix with all the Tags and within the size bounds.
+// Zero day counts and sizes are not set. S3 assigns an ID to a rule written
+// without one; EnsureBucket matches such a rule whatever its assigned ID.
+type LifecycleRule struct {
+	ID      string
+	Prefix  string
+	Enabled bool
+
+	// Tags the objects must have, and the sizes in bytes they must be
+	// greater or less than.
+	Tags                  map[string]string
+	ObjectSizeGreaterThan int64
+	ObjectSizeLessThan    int64
+
+	// ExpirationDays is the age at which current versions expire.
+	ExpirationDays int64
+
+	// NoncurrentExpirationDays is the time after becoming noncurrent at which
+	// versions are deleted.
+	NoncurrentExpirationDays int64
+
+	// AbortIncompleteUploadDays is the age at which incomplete multipart
+	// uploads are aborted.
+	AbortIncompleteUploadDays int64
+
+	Transitions []LifecycleTransition
+}
+
+// LifecycleTransition moves objects to StorageClass at an age in Days.
+type LifecycleTransition struct {
+	Days         int64
+	StorageClass string
+}
+
+// PublicAccessBlock is the public access block configuration of a bucket.
+type PublicAccessBlock struct {
+	BlockPublicAcls       bool
+	IgnorePublicAcls      bool
+	BlockPublicPolicy     bool
+	RestrictPublicBuckets bool
+}
+
+// CORSRule is a rule of the bucket CORS configuration.
+type CORSRule struct {
+	ID             string
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposeHeaders  []string
+	MaxAgeSeconds  int64
+}
+
+// BucketConfig is the configuration EnsureBucket converges a bucket to.
+// Nil fields are left as they are; empty slices remove the configuration.
+type BucketConfig struct {
+	// Region the bucket is created in when it does not exist, or the region
+	// of the client when empty.
+	Region string
+
+	Policy            *policy.IAMPolicy
+	Versioning        *bool
+	Lifecycle         []LifecycleRule
+	PublicAccessBlock *PublicAccessBlock
+
+	// Encryption is the default encryption; CustomerKey may not be set.
+	Encryption *Encryption
+
+	CORS []CORSRule
+}
+
+// EnsureBucket creates the bucket if needed and applies each setting of cfg
+// that differs from the bucket. See EnsureBucketWithContext.
+func (svc *Service) EnsureBucket(cfg *BucketConfig) ([]string, error) {
+	return svc.EnsureBucketWithContext(context.TODO(), cfg)
+}
+
+// EnsureBucketWithContext creates the bucket if it does not exist and applies
+// each setting of cfg that differs from the bucket, returning the names of
+// those changed, e.g. "create", "policy" and "versioning". Settings already in
+// place are not written again, so EnsureBucket may be run repeatedly.
+func (svc *Service) EnsureBucketWithContext(ctx context.Context, cfg *BucketConfig) ([]string, error) {
+	if cfg == nil {
+		return nil, errors.New("ensure bucket: nil BucketConfig")
+	}
+	var changed []string
+	exists, err := svc.bucketExists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		in := &s3.CreateBucketInput{Bucket: aws.String(svc.name)}
+		if cfg.Region != "" && cfg.Region != "us-east-1" {
+			in.CreateBucketConfiguration = &s3.CreateBucketConfiguration{LocationConstraint: aws.String(cfg.Region)}
+		}
+		if _, err := svc.svc.CreateBucketWithContext(ctx, in); err != nil {
+			return nil, err
+		}
+		changed = append(changed, "create")
+	}
+	// the public access block goes first, so a policy it would block is not applied
+	if cfg.PublicAccessBlock != nil {
+		cur, err := svc.GetPublicAccessBlockWithContext(ctx)
+		if err != nil {
+			return changed, err
+		}
+		if cur == nil || *cur != *cfg.PublicAccessBlock {
+			if err := svc.SetPublicAccessBlockWithContext(ctx, cfg.PublicAccessBlock); err != nil {
+				return changed, err
+			}
+			changed = append(changed, "public-access-block")
+		}
+	}
+	if cfg.Policy != nil {
+		cur, err := svc.GetBucketPolicyWithContext(ctx)
+		if err != nil {
+			return changed, err
+		}
+		same, err := policiesEqual(cur, cfg.Policy)
+		if err != nil {
+			return changed, err
+		}
+		if !same {
+			if err := svc.SetBucketPolicyWithContext(ctx, cfg.Policy); err != nil {
+				return changed, err
+			}
+			changed = append(changed, "policy")
+		}
+	}
+	if cfg.Versioning != nil {
+		cur, err := svc.GetBucketVersioningWithContext(ctx)
+		if err != nil {
+			return changed, err
+		}
+		if cur != *cfg.Versioning {
+			if err := svc.SetBucketVersioningWithContext(ctx, *cfg.Versioning); err != nil {
+				return changed, err
+			}
+			changed = append(changed, "versioning")
+		}
+	}
+	if cfg.Lifecycle != nil {
+		cur, err := svc.GetBucketLifecycleWithContext(ctx)
+		if err != nil {
+			return changed, err
+		}
+		if !lifecycleEqual(cur, cfg.Lifecycle) {
+			if err := svc.SetBucketLifecycleWithContext(ctx, cfg.Lifecycle); err != nil {
+				return changed, err
+			}
+			changed = append(changed, "lifecycle")
+		}
+	}
+	if cfg.Encryption != nil {
+		cur, err := svc.GetBucketEncryptionWithContext(ctx)
+		if err != nil {
+			return changed, err
+		}
+		if !encryptionEqual(cur, cfg.Encryption) {
+			if err := svc.SetBucketEncryptionWithContext(ctx, cfg.Encryption); err != nil {
+				return changed, err
+			}
+			changed = append(changed, "encryption")
+		}
+	}
+	if cfg.CORS != nil {
+		cur, err := svc.GetBucketCORSWithContext(ctx)
+		if err != nil {
+			return changed, err
+		}
+		if !reflect.DeepEqual(normalCORS(cur), normalCORS(cfg.CORS)) {
+			if err := svc.SetBucketCORSWithContext(ctx, cfg.CORS); err != nil {
+				return changed, err
+			}
+			changed = append(changed, "cors")
+		}
+	}
+	return changed, nil
+}
+
+func (svc *Service) bucketExists(ctx context.Context) (bool, error) {
+	_, err := svc.svc.HeadBucketWithContext(ctx, &s3.HeadBucketInput{Bucket: aws.String(svc.name)})
+	switch {
+	case err == nil:
+		return true, nil
+	case hasErrorCode(err, "NotFound", s3.ErrCodeNoSuchBucket):
+		return false, nil
+	}
+	return false, err
+}
+
+// GetBucketPolicy returns the policy of the bucket, or nil if it has none.
+// See GetBucketPolicyWithContext.
+func (svc *Service) GetBucketPolicy() (*policy.IAMPolicy, error) {
+	return svc.GetBucketPolicyWithContext(context.TODO())
+}
+
+// GetBucketPolicyWithContext returns the policy of the bucket, or nil if it
+// has none.
+func (svc *Service) GetBucketPolicyWithContext(ctx context.Context) (*policy.IAMPolicy, error) {
+	res, err := svc.svc.GetBucketPolicyWithContext(ctx, &s3.GetBucketPolicyInput{Bucket: aws.String(svc.name)})
+	if hasErrorCode(err, "NoSuchBucketPolicy") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var p policy.IAMPolicy
+	if err := json.Unmarshal([]byte(aws.StringValue(res.Policy)), &p); err != nil {
+		return nil, fmt.Errorf("bucket policy: %w", err)
+	}
+	return &p, nil
+}
+
+// SetBucketPolicy replaces the policy of the bucket, or deletes it if p is
+// nil.
+// See SetBucketPolicyWithContext.
+func (svc *Service) SetBucketPolicy(p *policy.IAMPolicy) error {
+	return svc.SetBucketPolicyWithContext(context.TODO(), p)
+}
+
+// SetBucketPolicyWithContext replaces the policy of the bucket, or deletes it
+// if p is nil.
+func (svc *Service) SetBucketPolicyWithContext(ctx context.Context, p *policy.IAMPolicy) error {
+	if p == nil {
+		_, err := svc.svc.DeleteBucketPolicyWithContext(ctx, &s3.DeleteBucketPolicyInput{Bucket: aws.String(svc.name)})
+		return err
+	}
+	doc, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	_, err = svc.svc.PutBucketPolicyWithContext(ctx, &s3.PutBucketPolicyInput{
+		Bucket: aws.String(svc.name),
+		Policy: aws.String(string(doc)),
+	})
+	return err
+}
+
+// policiesEqual compares the JSON of two policies, either of which may be nil.
+func policiesEqual(a, b *policy.IAMPolicy) (bool, error) {
+	if a == nil || b == nil {
+		return a == b, nil
+	}
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+	y, err := json.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+	return policy.Equal(x, y)
+}
+
+// GetBucketVersioning reports whether versioning is enabled on the bucket.
+// See GetBucketVersioningWithContext.
+func (svc *Service) GetBucketVersioning() (bool, error) {
+	return svc.GetBucketVersioningWithContext(context.TODO())
+}
+
+// GetBucketVersioningWithContext reports whether versioning is enabled on the
+// bucket. Suspended versioning is reported as disabled.
+func (svc *Service) GetBucketVersioningWithContext(ctx context.Context) (bool, error) {
+	res, err := svc.svc.GetBucketVersioningWithContext(ctx, &s3.GetBucketVersioningInput{Bucket: aws.String(svc.name)})
+	if err != nil {
+		return false, err
+	}
+	return aws.StringValue(res.Status) == s3.BucketVersioningStatusEnabled, nil
+}
+
+// SetBucketVersioning enables or suspends versioning on the bucket.
+// See SetBucketVersioningWithContext.
+func (svc *Service) SetBucketVersioning(enabled bool) error {
+	return svc.SetBucketVersioningWithContext(context.TODO(), enabled)
+}
+
+// SetBucketVersioningWithContext enables or suspends versioning on the bucket.
+func (svc *Service) SetBucketVersioningWithContext(ctx context.Context, enabled bool) error {
+	status := s3.BucketVersioningStatusSuspended
+	if enabled {
+		status = s3.BucketVersioningStatusEnabled
+	}
+	_, err := svc.svc.PutBucketVersioningWithContext(ctx, &s3.PutBucketVersioningInput{
+		Bucket:                  aws.String(svc.name),
+		VersioningConfiguration: &s3.VersioningConfiguration{Status: aws.String(status)},
+	})
+	return err
+}
+
+// GetBucketLifecycle returns the lifecycle rules of the bucket, or nil if it
+// has none.
+// See GetBucketLifecycleWithContext.
+func (svc *Service) GetBucketLifecycle() ([]LifecycleRule, error) {
+	return svc.GetBucketLifecycleWithContext(context.TODO())
+}
+
+// GetBucketLifecycleWithContext returns the lifecycle rules of the bucket, or
+// nil if it has none.
+func (svc *Service) GetBucketLifecycleWithContext(ctx context.Context) ([]LifecycleRule, error) {
+	res, err := svc.svc.GetBucketLifecycleConfigurationWithContext(ctx, &s3.GetBucketLifecycleConfigurationInput{Bucket: aws.String(svc.name)})
+	if hasErrorCode(err, "NoSuchLifecycleConfiguration") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]LifecycleRule, len(res.Rules))
+	for i, r := range res.Rules {
+		rule := LifecycleRule{
+			ID:      aws.StringValue(r.ID),
+			Prefix:  aws.StringValue(r.Prefix),
+			Enabled: aws.StringValue(r.Status) == s3.ExpirationStatusEnabled,
+		}
+		if f := r.Filter; f != nil {
+			rule.Prefix = aws.StringValue(f.Prefix)
+			rule.ObjectSizeGreaterThan = aws.Int64Value(f.ObjectSizeGreaterThan)
+			rule.ObjectSizeLessThan = aws.Int64Value(f.ObjectSizeLessThan)
+			if f.Tag != nil {
+				rule.Tags = map[string]string{aws.StringValue(f.Tag.Key): aws.StringValue(f.Tag.Value)}
+			}
+			if a := f.And; a != nil {
+				rule.Prefix = aws.StringValue(a.Prefix)
+				rule.ObjectSizeGreaterThan = aws.Int64Value(a.ObjectSizeGreaterThan)
+				rule.ObjectSizeLessThan = aws.Int64Value(a.ObjectSizeLessThan)
+				rule.Tags = tagMap(a.Tags)
+			}
+		}
+		if r.Expiration != nil {
+			rule.ExpirationDays = aws.Int64Value(r.Expiration.Days)
+		}
+		if r.NoncurrentVersionExpiration != nil {
+			rule.NoncurrentExpirationDays = aws.Int64Value(r.NoncurrentVersionExpiration.NoncurrentDays)
+		}
+		if r.AbortIncompleteMultipartUpload != nil {
+			rule.AbortIncompleteUploadDays = aws.Int64Value(r.AbortIncompleteMultipartUpload.DaysAfterInitiation)
+		}
+		for _, t := range r.Transitions {
+			rule.Transitions = append(rule.Transitions, LifecycleTransition{
+				Days:         aws.Int64Value(t.Days),
+				StorageClass: aws.StringValue(t.StorageClass),
+			})
+		}
+		rules[i] = rule
+	}
+	return rules, nil
+}
+
+// SetBucketLifecycle replaces the lifecycle rules of the bucket, or deletes
+// them if rules is empty.
+// See SetBucketLifecycleWithContext.
+func (svc *Service) SetBucketLifecycle(rules []LifecycleRule) error {
+	return svc.SetBucketLifecycleWithContext(context.TODO(), rules)
+}
+
+// SetBucketLifecycleWithContext replaces the lifecycle rules of the bucket, or
+// deletes them if rules is empty.
+func (svc *Service) SetBucketLifecycleWithContext(ctx context.Context, rules []LifecycleRule) error {
+	if len(rules) == 0 {
+		_, err := svc.svc.DeleteBucketLifecycleWithContext(ctx, &s3.DeleteBucketLifecycleInput{Bucket: aws.String(svc.name)})
+		return err
+	}
+	cfg := &s3.BucketLifecycleConfiguration{}
+	for _, r := range rules {
+		status := s3.ExpirationStatusDisabled
+		if r.Enabled {
+			status = s3.ExpirationStatusEnabled
+		}
+		rule := &s3.LifecycleRule{
+			Filter: lifecycleFilter(r),
+			Status: aws.String(status),
+		}
+		if r.ID != "" {
+			rule.ID = aws.String(r.ID)
+		}
+		if r.ExpirationDays > 0 {
+			rule.Expiration = &s3.LifecycleExpiration{Days: aws.Int64(r.ExpirationDays)}
+		}
+		if r.NoncurrentExpirationDays > 0 {
+			rule.NoncurrentVersionExpiration = &s3.NoncurrentVersionExpiration{NoncurrentDays: aws.Int64(r.NoncurrentExpirationDays)}
+		}
+		if r.AbortIncompleteUploadDays > 0 {
+			rule.AbortIncompleteMultipartUpload = &s3.AbortIncompleteMultipartUpload{DaysAfterInitiation: aws.Int64(r.AbortIncompleteUploadDays)}
+		}
+		for _, t := range r.Transitions {
+			rule.Transitions = append(rule.Transitions, &s3.Transition{
+				Days:         aws.Int64(t.Days),
+				StorageClass: aws.String(t.StorageClass),
+			})
+		}
+		cfg.Rules = append(cfg.Rules, rule)
+	}
+	_, err := svc.svc.PutBucketLifecycleConfigurationWithContext(ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(svc.name),
+		LifecycleConfiguration: cfg,
+	})
+	return err
+}
+
+// GetPublicAccessBlock returns the public access block of the bucket, or nil
+// if it has none.
+// See GetPublicAccessBlockWithContext.
+func (svc *Service) GetPublicAccessBlock() (*PublicAccessBlock, error) {
+	return svc.GetPublicAccessBlockWithContext(context.TODO())
+}
+
+// GetPublicAccessBlockWithContext returns the public access block of the
+// bucket, or nil if it has none.
+func (svc *Service) GetPublicAccessBlockWithContext(ctx context.Context) (*PublicAccessBlock, error) {
+	res, err := svc.svc.GetPublicAccessBlockWithContext(ctx, &s3.GetPublicAccessBlockInput{Bucket: aws.String(svc.name)})
+	if hasErrorCode(err, "NoSuchPublicAccessBlockConfiguration") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := res.PublicAccessBlockConfiguration
+	if c == nil {
+		return nil, nil
+	}
+	return &PublicAccessBlock{
+		BlockPublicAcls:       aws.BoolValue(c.BlockPublicAcls),
+		IgnorePublicAcls:      aws.BoolValue(c.IgnorePublicAcls),
+		BlockPublicPolicy:     aws.BoolValue(c.BlockPublicPolicy),
+		RestrictPublicBuckets: aws.BoolValue(c.RestrictPublicBuckets),
+	}, nil
+}
+
+// SetPublicAccessBlock replaces the public access block of the bucket, or
+// deletes it if b is nil.
+// See SetPublicAccessBlockWithContext.
+func (svc *Service) SetPublicAccessBlock(b *PublicAccessBlock) error {
+	return svc.SetPublicAccessBlockWithContext(context.TODO(), b)
+}
+
+// SetPublicAccessBlockWithContext replaces the public access block of the
+// bucket, or deletes it if b is nil.
+func (svc *Service) SetPublicAccessBlockWithContext(ctx context.Context, b *PublicAccessBlock) error {
+	if b == nil {
+		_, err := svc.svc.DeletePublicAccessBlockWithContext(ctx, &s3.DeletePublicAccessBlockInput{Bucket: aws.String(svc.name)})
+		return err
+	}
+	_, err := svc.svc.PutPublicAccessBlockWithContext(ctx, &s3.PutPublicAccessBlockInput{
+		Bucket: aws.String(svc.name),
+		PublicAccessBlockConfiguration: &s3.PublicAccessBlockConfiguration{
+			BlockPublicAcls:       aws.Bool(b.BlockPublicAcls),
+			IgnorePublicAcls:      aws.Bool(b.IgnorePublicAcls),
+			BlockPublicPolicy:     aws.Bool(b.BlockPublicPolicy),
+			RestrictPublicBuckets: aws.Bool(b.RestrictPublicBuckets),
+		},
+	})
+	return err
+}
+
+// GetBucketEncryption returns the default encryption of the bucket, or nil if
+// it has none.
+// See GetBucketEncryptionWithContext.
+func (svc *Service) GetBucketEncryption() (*Encryption, error) {
+	return svc.GetBucketEncryptionWithContext(context.TODO())
+}
+
+// GetBucketEncryptionWithContext returns the default encryption of the bucket,
+// or nil if it has none. Unlike SetEncryption, which applies to the objects
+// the Service writes, this is applied by S3 to objects written without
+// encryption settings.
+func (svc *Service) GetBucketEncryptionWithContext(ctx context.Context) (*Encryption, error) {
+	res, err := svc.svc.GetBucketEncryptionWithContext(ctx, &s3.GetBucketEncryptionInput{Bucket: aws.String(svc.name)})
+	if hasErrorCode(err, "ServerSideEncryptionConfigurationNotFoundError") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if res.ServerSideEncryptionConfiguration == nil {
+		return nil, nil
+	}
+	for _, r := range res.ServerSideEncryptionConfiguration.Rules {
+		if d := r.ApplyServerSideEncryptionByDefault; d != nil {
+			return &Encryption{
+				Algorithm: aws.StringValue(d.SSEAlgorithm),
+				KMSKeyID:  aws.StringValue(d.KMSMasterKeyID),
+				BucketKey: aws.BoolValue(r.BucketKeyEnabled),
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// SetBucketEncryption replaces the default encryption of the bucket with the
+// Algorithm, KMSKeyID and BucketKey of e, or deletes it if e is nil.
+// See SetBucketEncryptionWithContext.
+func (svc *Service) SetBucketEncryption(e *Encryption) error {
+	return svc.SetBucketEncryptionWithContext(context.TODO(), e)
+}
+
+// SetBucketEncryptionWithContext replaces the default encryption of the bucket
+// with the Algorithm, KMSKeyID and BucketKey of e, or deletes it if e is nil.
+func (svc *Service) SetBucketEncryptionWithContext(ctx context.Context, e *Encryption) error {
+	if e == nil {
+		_, err := svc.svc.DeleteBucketEncryptionWithContext(ctx, &s3.DeleteBucketEncryptionInput{Bucket: aws.String(svc.name)})
+		return err
+	}
+	if len(e.CustomerKey) > 0 {
+		return errors.New("bucket encryption: SSE-C cannot be a bucket default")
+	}
+	def := &s3.ServerSideEncryptionByDefault{SSEAlgorithm: aws.String(e.Algorithm)}
+	if e.KMSKeyID != "" {
+		def.KMSMasterKeyID = aws.String(e.KMSKeyID)
+	}
+	_, err := svc.svc.PutBucketEncryptionWithContext(ctx, &s3.PutBucketEncryptionInput{
+		Bucket: aws.String(svc.name),
+		ServerSideEncryptionConfiguration: &s3.ServerSideEncryptionConfiguration{
+			Rules: []*s3.ServerSideEncryptionRule{{
+				ApplyServerSideEncryptionByDefault: def,
+				BucketKeyEnabled:                   aws.Bool(e.BucketKey),
+			}},
+		},
+	})
+	return err
+}
+
+// GetBucketCORS returns the CORS rules of the bucket, or nil if it has none.
+// See GetBucketCORSWithContext.
+func (svc *Service) GetBucketCORS() ([]CORSRule, error) {
+	return svc.GetBucketCORSWithContext(context.TODO())
+}
+
+// GetBucketCORSWithContext returns the CORS rules of the bucket, or nil if it
+// has none.
+func (svc *Service) GetBucketCORSWithContext(ctx context.Context) ([]CORSRule, error) {
+	res, err := svc.svc.GetBucketCorsWithContext(ctx, &s3.GetBucketCorsInput{Bucket: aws.String(svc.name)})
+	if hasErrorCode(err, "NoSuchCORSConfiguration") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]CORSRule, len(res.CORSRules))
+	for i, r := range res.CORSRules {
+		rules[i] = CORSRule{
+			ID:             aws.StringValue(r.ID),
+			AllowedOrigins: aws.StringValueSlice(r.AllowedOrigins),
+			AllowedMethods: aws.StringValueSlice(r.AllowedMethods),
+			AllowedHeaders: aws.StringValueSlice(r.AllowedHeaders),
+			ExposeHeaders:  aws.StringValueSlice(r.ExposeHeaders),
+			MaxAgeSeconds:  aws.Int64Value(r.MaxAgeSeconds),
+		}
+	}
+	return rules, nil
+}
+
+// SetBucketCORS replaces the CORS rules of the bucket, or deletes them if
+// rules is empty.
+// See SetBucketCORSWithContext.
+func (svc *Service) SetBucketCORS(rules []CORSRule) error {
+	return svc.SetBucketCORSWithContext(context.TODO(), rules)
+}
+
+// SetBucketCORSWithContext replaces the CORS rules of the bucket, or deletes
+// them if rules is empty.
+func (svc *Service) SetBucketCORSWithContext(ctx context.Context, rules []CORSRule) error {
+	if len(rules) == 0 {
+		_, err := svc.svc.DeleteBucketCorsWithContext(ctx, &s3.DeleteBucketCorsInput{Bucket: aws.String(svc.name)})
+		return err
+	}
+	cfg := &s3.CORSConfiguration{}
+	for _, r := range rules {
+		rule := &s3.CORSRule{
+			AllowedOrigins: aws.StringSlice(r.AllowedOrigins),
+			AllowedMethods: aws.StringSlice(r.AllowedMethods),
+		}
+		if r.ID != "" {
+			rule.ID = aws.String(r.ID)
+		}
+		if len(r.AllowedHeaders) > 0 {
+			rule.AllowedHeaders = aws.StringSlice(r.AllowedHeaders)
+		}
+		if len(r.ExposeHeaders) > 0 {
+			rule.ExposeHeaders = aws.StringSlice(r.ExposeHeaders)
+		}
+		if r.MaxAgeSeconds > 0 {
+			rule.MaxAgeSeconds = aws.Int64(r.MaxAgeSeconds)
+		}
+		cfg.CORSRules = append(cfg.CORSRules, rule)
+	}
+	_, err := svc.svc.PutBucketCorsWithContext(ctx, &s3.PutBucketCorsInput{
+		Bucket:            aws.String(svc.name),
+		CORSConfiguration: cfg,
+	})
+	return err
+}
+
+// lifecycleFilter returns the filter of the rule: a single condition on its
+// own, as S3 requires, or an And of them.
+func lifecycleFilter(r LifecycleRule) *s3.LifecycleRuleFilter {
+	n := len(r.Tags)
+	if r.Prefix != "" {
+		n++
+	}
+	if r.ObjectSizeGreaterThan > 0 {
+		n++
+	}
+	if r.ObjectSizeLessThan > 0 {
+		n++
+	}
+	f := &s3.LifecycleRuleFilter{}
+	switch {
+	case n > 1:
+		f.And = &s3.LifecycleRuleAndOperator{Tags: tagSet(r.Tags)}
+		if r.Prefix != "" {
+			f.And.Prefix = aws.String(r.Prefix)
+		}
+		if r.ObjectSizeGreaterThan > 0 {
+			f.And.ObjectSizeGreaterThan = aws.Int64(r.ObjectSizeGreaterThan)
+		}
+		if r.ObjectSizeLessThan > 0 {
+			f.And.ObjectSizeLessThan = aws.Int64(r.ObjectSizeLessThan)
+		}
+	case len(r.Tags) == 1:
+		f.Tag = tagSet(r.Tags)[0]
+	case r.ObjectSizeGreaterThan > 0:
+		f.ObjectSizeGreaterThan = aws.Int64(r.ObjectSizeGreaterThan)
+	case r.ObjectSizeLessThan > 0:
+		f.ObjectSizeLessThan = aws.Int64(r.ObjectSizeLessThan)
+	default:
+		f.Prefix = aws.String(r.Prefix)
+	}
+	return f
+}
+
+// encryptionEqual reports whether the current default encryption of a bucket,
+// which may be nil, matches that wanted.
+func encryptionEqual(cur, want *Encryption) bool {
+	return cur != nil && cur.Algorithm == want.Algorithm && cur.BucketKey == want.BucketKey &&
+		normalKMSKeyID(cur.KMSKeyID) == normalKMSKeyID(want.KMSKeyID)
+}
+
+// normalKMSKeyID returns the KMS key ID, key ARN, alias name or alias ARN id
+// as "key/<id>" or "alias/<name>", for comparison, since S3 may report the
+// ARN of a key set by ID. An alias and the key it names are not resolved, so
+// they compare unequal.
+func normalKMSKeyID(id string) string {
+	if strings.HasPrefix(id, "arn:") {
+		// arn:partition:kms:region:account:key/<id> or alias/<name>
+		if parts := strings.SplitN(id, ":", 6); len(parts) == 6 {
+			return parts[5]
+		}
+		return id
+	}
+	if id == "" || strings.HasPrefix(id, "alias/") {
+		return id
+	}
+	return "key/" + id
+}
+
+// lifecycleEqual reports whether the current rules of a bucket match those
+// wanted. The ID S3 assigned to a current rule is ignored when the wanted
+// rule has none.
+func lifecycleEqual(cur, want []LifecycleRule) bool {
+	cur, want = normalLifecycle(cur), normalLifecycle(want)
+	if len(cur) != len(want) {
+		return false
+	}
+	for i := range want {
+		if want[i].ID == "" {
+			cur[i].ID = ""
+		}
+	}
+	return reflect.DeepEqual(cur, want)
+}
+
+// normalLifecycle returns a copy of rules with empty slices and maps as nil,
+// for comparison.
+func normalLifecycle(rules []LifecycleRule) []LifecycleRule {
+	if len(rules) == 0 {
+		return nil
+	}
+	out := append([]LifecycleRule{}, rules...)
+	for i := range out {
+		if len(out[i].Transitions) == 0 {
+			out[i].Transitions = nil
+		}
+		if len(out[i].Tags) == 0 {
+			out[i].Tags = nil
+		}
+	}
+	return out
+}
+
+// normalCORS returns a copy of rules with empty slices as nil, for comparison.
+func normalCORS(rules []CORSRule) []CORSRule {
+	if len(rules) == 0 {
+		return nil
+	}
+	out := append([]CORSRule{}, rules...)
+	for i := range out {
+		for _, s := range []*[]string{&out[i].AllowedOrigins, &out[i].AllowedMethods, &out[i].AllowedHeaders, &out[i].ExposeHeaders} {
+			if len(*s) == 0 {
+				*s = nil
+			}
+		}
+	}
+	return out
+}
+
+// hasErrorCode reports whether err is an AWS error with one of the codes.
+func hasErrorCode(err error, codes ...string) bool {
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) {
+		return false
+	}
+	for _, c := range codes {
+		if aerr.Code() == c {
+			return true
+		}
+	}
+	return false
+}
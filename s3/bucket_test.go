@@ -0,0 +1,261 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cleardataeng/aidews/policy"
+	"github.com/cleardataeng/aidews/s3/extmocks/github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+)
+
+var testBucketConfig = &BucketConfig{
+	Region: "us-west-2",
+	Policy: &policy.IAMPolicy{
+		Version: "2012-10-17",
+		Statement: []policy.IAMPolicyStatement{{
+			Effect:    "Deny",
+			Action:    policy.StrOrSlice{"s3:*"},
+			Resource:  policy.StrOrSlice{"arn:aws:s3:::movement-keys/*"},
+			Principal: map[string]policy.StrOrSlice{"AWS": {"*"}},
+			Condition: map[string]interface{}{"Bool": map[string]interface{}{"aws:SecureTransport": "false"}},
+		}},
+	},
+	Versioning: aws.Bool(true),
+	Lifecycle: []LifecycleRule{{
+		ID:                        "logs",
+		Prefix:                    "logs/",
+		Enabled:                   true,
+		ExpirationDays:            90,
+		AbortIncompleteUploadDays: 7,
+		Transitions:               []LifecycleTransition{{Days: 30, StorageClass: s3.TransitionStorageClassGlacierIr}},
+	}},
+	PublicAccessBlock: &PublicAccessBlock{BlockPublicAcls: true, IgnorePublicAcls: true, BlockPublicPolicy: true, RestrictPublicBuckets: true},
+	Encryption:        &Encryption{Algorithm: SSEKMS, KMSKeyID: "alias/movement", BucketKey: true},
+	CORS:              []CORSRule{{AllowedOrigins: []string{"https://example.com"}, AllowedMethods: []string{"GET"}}},
+}
+
+func TestService_EnsureBucketCreates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	notFound := func(code string) error { return awserr.New(code, "", nil) }
+	gomock.InOrder(
+		s3Mock.EXPECT().HeadBucketWithContext(ctx, gomock.Any()).Return(nil, notFound("NotFound")),
+		s3Mock.EXPECT().CreateBucketWithContext(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, in *s3.CreateBucketInput, _ ...request.Option) (*s3.CreateBucketOutput, error) {
+				if got := aws.StringValue(in.CreateBucketConfiguration.LocationConstraint); got != "us-west-2" {
+					t.Errorf(`location: want: "us-west-2", got: "%s"`, got)
+				}
+				return &s3.CreateBucketOutput{}, nil
+			},
+		),
+		s3Mock.EXPECT().GetPublicAccessBlockWithContext(ctx, gomock.Any()).Return(nil, notFound("NoSuchPublicAccessBlockConfiguration")),
+		s3Mock.EXPECT().PutPublicAccessBlockWithContext(ctx, gomock.Any()).Return(&s3.PutPublicAccessBlockOutput{}, nil),
+		s3Mock.EXPECT().GetBucketPolicyWithContext(ctx, gomock.Any()).Return(nil, notFound("NoSuchBucketPolicy")),
+		s3Mock.EXPECT().PutBucketPolicyWithContext(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, in *s3.PutBucketPolicyInput, _ ...request.Option) (*s3.PutBucketPolicyOutput, error) {
+				want := `{"Version":"2012-10-17","Statement":[{"Effect":"Deny","Action":"s3:*","Resource":"arn:aws:s3:::movement-keys/*","Principal":{"AWS":"*"},"Condition":{"Bool":{"aws:SecureTransport":"false"}}}]}`
+				if same, err := policy.Equal([]byte(want), []byte(aws.StringValue(in.Policy))); err != nil || !same {
+					t.Errorf(`policy: want: %s, got: %s`, want, aws.StringValue(in.Policy))
+				}
+				return &s3.PutBucketPolicyOutput{}, nil
+			},
+		),
+		s3Mock.EXPECT().GetBucketVersioningWithContext(ctx, gomock.Any()).Return(&s3.GetBucketVersioningOutput{}, nil),
+		s3Mock.EXPECT().PutBucketVersioningWithContext(ctx, gomock.Any()).Return(&s3.PutBucketVersioningOutput{}, nil),
+		s3Mock.EXPECT().GetBucketLifecycleConfigurationWithContext(ctx, gomock.Any()).Return(nil, notFound("NoSuchLifecycleConfiguration")),
+		s3Mock.EXPECT().PutBucketLifecycleConfigurationWithContext(ctx, gomock.Any()).Return(&s3.PutBucketLifecycleConfigurationOutput{}, nil),
+		s3Mock.EXPECT().GetBucketEncryptionWithContext(ctx, gomock.Any()).Return(nil, notFound("ServerSideEncryptionConfigurationNotFoundError")),
+		s3Mock.EXPECT().PutBucketEncryptionWithContext(ctx, gomock.Any()).Return(&s3.PutBucketEncryptionOutput{}, nil),
+		s3Mock.EXPECT().GetBucketCorsWithContext(ctx, gomock.Any()).Return(nil, notFound("NoSuchCORSConfiguration")),
+		s3Mock.EXPECT().PutBucketCorsWithContext(ctx, gomock.Any()).Return(&s3.PutBucketCorsOutput{}, nil),
+	)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	changed, err := svc.EnsureBucketWithContext(ctx, testBucketConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"create", "public-access-block", "policy", "versioning", "lifecycle", "encryption", "cors"}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf(`changed: want: %v, got: %v`, want, changed)
+	}
+}
+
+func TestService_EnsureBucketUnchanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	// the bucket already matches, as S3 reports it, so nothing is put
+	s3Mock.EXPECT().HeadBucketWithContext(ctx, gomock.Any()).Return(&s3.HeadBucketOutput{}, nil)
+	s3Mock.EXPECT().GetPublicAccessBlockWithContext(ctx, gomock.Any()).Return(&s3.GetPublicAccessBlockOutput{
+		PublicAccessBlockConfiguration: &s3.PublicAccessBlockConfiguration{
+			BlockPublicAcls:       aws.Bool(true),
+			IgnorePublicAcls:      aws.Bool(true),
+			BlockPublicPolicy:     aws.Bool(true),
+			RestrictPublicBuckets: aws.Bool(true),
+		},
+	}, nil)
+	s3Mock.EXPECT().GetBucketPolicyWithContext(ctx, gomock.Any()).Return(&s3.GetBucketPolicyOutput{
+		Policy: aws.String(`{"Version":"2012-10-17","Statement":[{"Effect":"Deny","Principal":{"AWS":"*"},"Action":"s3:*","Resource":"arn:aws:s3:::movement-keys/*","Condition":{"Bool":{"aws:SecureTransport":"false"}}}]}`),
+	}, nil)
+	s3Mock.EXPECT().GetBucketVersioningWithContext(ctx, gomock.Any()).Return(&s3.GetBucketVersioningOutput{
+		Status: aws.String(s3.BucketVersioningStatusEnabled),
+	}, nil)
+	s3Mock.EXPECT().GetBucketLifecycleConfigurationWithContext(ctx, gomock.Any()).Return(&s3.GetBucketLifecycleConfigurationOutput{
+		Rules: []*s3.LifecycleRule{{
+			ID:                             aws.String("logs"),
+			Filter:                         &s3.LifecycleRuleFilter{Prefix: aws.String("logs/")},
+			Status:                         aws.String(s3.ExpirationStatusEnabled),
+			Expiration:                     &s3.LifecycleExpiration{Days: aws.Int64(90)},
+			AbortIncompleteMultipartUpload: &s3.AbortIncompleteMultipartUpload{DaysAfterInitiation: aws.Int64(7)},
+			Transitions:                    []*s3.Transition{{Days: aws.Int64(30), StorageClass: aws.String(s3.TransitionStorageClassGlacierIr)}},
+		}},
+	}, nil)
+	s3Mock.EXPECT().GetBucketEncryptionWithContext(ctx, gomock.Any()).Return(&s3.GetBucketEncryptionOutput{
+		ServerSideEncryptionConfiguration: &s3.ServerSideEncryptionConfiguration{
+			Rules: []*s3.ServerSideEncryptionRule{{
+				ApplyServerSideEncryptionByDefault: &s3.ServerSideEncryptionByDefault{
+					SSEAlgorithm:   aws.String(SSEKMS),
+					KMSMasterKeyID: aws.String("alias/movement"),
+				},
+				BucketKeyEnabled: aws.Bool(true),
+			}},
+		},
+	}, nil)
+	s3Mock.EXPECT().GetBucketCorsWithContext(ctx, gomock.Any()).Return(&s3.GetBucketCorsOutput{
+		CORSRules: []*s3.CORSRule{{
+			AllowedOrigins: aws.StringSlice([]string{"https://example.com"}),
+			AllowedMethods: aws.StringSlice([]string{"GET"}),
+		}},
+	}, nil)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	changed, err := svc.EnsureBucketWithContext(ctx, testBucketConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 0 {
+		t.Errorf(`changed: want none, got: %v`, changed)
+	}
+}
+
+func TestService_EnsureBucketLifecycleUnchanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	cfg := &BucketConfig{Lifecycle: []LifecycleRule{
+		{Prefix: "tmp/", Enabled: true, ExpirationDays: 1},
+		{Tags: map[string]string{"class": "scratch"}, Enabled: true, ExpirationDays: 7},
+		{ID: "archive", Prefix: "data/", Tags: map[string]string{"class": "archive"}, ObjectSizeGreaterThan: 1 << 20, Enabled: true,
+			Transitions: []LifecycleTransition{{Days: 30, StorageClass: s3.TransitionStorageClassGlacier}}},
+	}}
+
+	// the rules written on the first run are read back, with the IDs S3
+	// assigns to those without, on the second
+	var written []*s3.LifecycleRule
+	s3Mock.EXPECT().HeadBucketWithContext(ctx, gomock.Any()).Return(&s3.HeadBucketOutput{}, nil).Times(2)
+	gomock.InOrder(
+		s3Mock.EXPECT().GetBucketLifecycleConfigurationWithContext(ctx, gomock.Any()).Return(nil, awserr.New("NoSuchLifecycleConfiguration", "", nil)),
+		s3Mock.EXPECT().PutBucketLifecycleConfigurationWithContext(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, in *s3.PutBucketLifecycleConfigurationInput, _ ...request.Option) (*s3.PutBucketLifecycleConfigurationOutput, error) {
+				written = in.LifecycleConfiguration.Rules
+				return &s3.PutBucketLifecycleConfigurationOutput{}, nil
+			},
+		),
+		s3Mock.EXPECT().GetBucketLifecycleConfigurationWithContext(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ *s3.GetBucketLifecycleConfigurationInput, _ ...request.Option) (*s3.GetBucketLifecycleConfigurationOutput, error) {
+				for i, r := range written {
+					if r.ID == nil {
+						r.ID = aws.String(fmt.Sprintf("Z2VuZXJhdGVk%d", i))
+					}
+				}
+				return &s3.GetBucketLifecycleConfigurationOutput{Rules: written}, nil
+			},
+		),
+	)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	changed, err := svc.EnsureBucketWithContext(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"lifecycle"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf(`changed: want: %v, got: %v`, want, changed)
+	}
+	if f := written[1].Filter; f.Tag == nil || aws.StringValue(f.Tag.Value) != "scratch" {
+		t.Errorf(`filter: want tag "class=scratch", got: %v`, f)
+	}
+	if f := written[2].Filter; f.And == nil || len(f.And.Tags) != 1 || aws.Int64Value(f.And.ObjectSizeGreaterThan) != 1<<20 {
+		t.Errorf(`filter: want prefix, tag and size, got: %v`, f)
+	}
+	changed, err = svc.EnsureBucketWithContext(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 0 {
+		t.Errorf(`changed: want none, got: %v`, changed)
+	}
+}
+
+func TestService_EnsureBucketEncryptionKey(t *testing.T) {
+	const keyID = "1234abcd-12ab-34cd-56ef-1234567890ab"
+	tests := map[string]struct {
+		want, cur string
+		changed   bool
+	}{
+		"key id as arn": {keyID, "arn:aws:kms:us-west-2:111122223333:key/" + keyID, false},
+		"key arn":       {"arn:aws:kms:us-west-2:111122223333:key/" + keyID, "arn:aws:kms:us-west-2:111122223333:key/" + keyID, false},
+		"alias as arn":  {"alias/movement", "arn:aws:kms:us-west-2:111122223333:alias/movement", false},
+		"other key":     {keyID, "arn:aws:kms:us-west-2:111122223333:key/0000abcd-12ab-34cd-56ef-1234567890ab", true},
+		"alias of key":  {"alias/movement", "arn:aws:kms:us-west-2:111122223333:key/" + keyID, true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			s3Mock := mock_s3iface.NewMockS3API(ctrl)
+			s3Mock.EXPECT().HeadBucketWithContext(ctx, gomock.Any()).Return(&s3.HeadBucketOutput{}, nil)
+			s3Mock.EXPECT().GetBucketEncryptionWithContext(ctx, gomock.Any()).Return(&s3.GetBucketEncryptionOutput{
+				ServerSideEncryptionConfiguration: &s3.ServerSideEncryptionConfiguration{
+					Rules: []*s3.ServerSideEncryptionRule{{
+						ApplyServerSideEncryptionByDefault: &s3.ServerSideEncryptionByDefault{
+							SSEAlgorithm:   aws.String(SSEKMS),
+							KMSMasterKeyID: aws.String(tt.cur),
+						},
+					}},
+				},
+			}, nil)
+			if tt.changed {
+				s3Mock.EXPECT().PutBucketEncryptionWithContext(ctx, gomock.Any()).Return(&s3.PutBucketEncryptionOutput{}, nil)
+			}
+
+			svc := newWithSvc(testBucket, s3Mock)
+			changed, err := svc.EnsureBucketWithContext(ctx, &BucketConfig{
+				Encryption: &Encryption{Algorithm: SSEKMS, KMSKeyID: tt.want},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(changed) > 0; got != tt.changed {
+				t.Errorf(`changed: want: %v, got: %v`, tt.changed, changed)
+			}
+		})
+	}
+}
+
+func TestService_EnsureBucketNilConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	svc := newWithSvc(testBucket, mock_s3iface.NewMockS3API(ctrl))
+	if _, err := svc.EnsureBucketWithContext(ctx, nil); err == nil {
+		t.Error("want error")
+	}
+}
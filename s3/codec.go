@@ -0,0 +1,252 @@
+package s3
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Codec marshals values into object content and unmarshals them back out.
+//
+// Codecs are registered by content type; ReadUnmarshal chooses the codec
+// matching the Content-Type of the object it reads.
+type Codec interface {
+	// ContentType is the media type stored with objects written by the codec.
+	ContentType() string
+
+	// ContentEncoding is the encoding stored with objects written by the codec.
+	// It is empty when the content is not encoded.
+	ContentEncoding() string
+
+	// Marshal returns the content for v.
+	Marshal(v interface{}) ([]byte, error)
+
+	// Unmarshal parses data into v.
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// Encoding compresses object content, e.g. gzip.
+//
+// Encodings are registered by name; ReadUnmarshal uses the encoding matching
+// the Content-Encoding of the object it reads.
+type Encoding interface {
+	// Name is the Content-Encoding value, e.g. "gzip".
+	Name() string
+
+	// NewReader returns a reader decoding r.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+
+	// NewWriter returns a writer encoding to w.
+	NewWriter(w io.Writer) io.WriteCloser
+}
+
+var (
+	// JSON marshals content with encoding/json.
+	JSON Codec = jsonCodec{}
+
+	// YAML marshals content with gopkg.in/yaml.v2.
+	YAML Codec = yamlCodec{}
+
+	// Gzip compresses content with compress/gzip.
+	Gzip Encoding = gzipEncoding{}
+
+	// Zstd compresses content with github.com/klauspost/compress/zstd.
+	Zstd Encoding = zstdEncoding{}
+
+	// GzipJSON marshals content with encoding/json and compresses it with gzip.
+	GzipJSON = Encoded(JSON, Gzip)
+)
+
+var (
+	registryMu sync.RWMutex
+	codecs     = map[string]Codec{}
+	encodings  = map[string]Encoding{}
+)
+
+func init() {
+	RegisterCodec(JSON)
+	RegisterCodec(YAML)
+	RegisterEncoding(Gzip)
+	RegisterEncoding(Zstd)
+}
+
+// RegisterCodec makes c available to ReadUnmarshal for its content type.
+// A codec registered for a content type replaces any previous one.
+func RegisterCodec(c Codec) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	codecs[mediaType(c.ContentType())] = c
+}
+
+// RegisterEncoding makes e available to ReadUnmarshal for its name.
+// An encoding registered for a name replaces any previous one.
+func RegisterEncoding(e Encoding) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	encodings[e.Name()] = e
+}
+
+func lookupCodec(contentType string) (Codec, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	c, ok := codecs[mediaType(contentType)]
+	return c, ok
+}
+
+func lookupEncoding(name string) (Encoding, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	e, ok := encodings[name]
+	return e, ok
+}
+
+// mediaType strips parameters, such as charset, from a content type.
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	return mt
+}
+
+// Encoded returns a Codec that marshals with c and then encodes with e.
+func Encoded(c Codec, e Encoding) Codec {
+	return encodedCodec{Codec: c, enc: e}
+}
+
+type encodedCodec struct {
+	Codec
+	enc Encoding
+}
+
+func (c encodedCodec) ContentEncoding() string {
+	return c.enc.Name()
+}
+
+func (c encodedCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := c.Codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w := c.enc.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c encodedCodec) Unmarshal(data []byte, v interface{}) error {
+	data, err := decode(c.enc, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	return c.Codec.Unmarshal(data, v)
+}
+
+// baseCodec returns the codec beneath any encoding.
+func baseCodec(c Codec) Codec {
+	if e, ok := c.(encodedCodec); ok {
+		return baseCodec(e.Codec)
+	}
+	return c
+}
+
+// decode reads all of r through the encoding e.
+func decode(e Encoding, r io.Reader) ([]byte, error) {
+	dr, err := e.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer dr.Close()
+	return ioutil.ReadAll(dr)
+}
+
+// unmarshalContent decodes data according to the content type and encoding
+// of the object it was read from. Content of an unregistered type is
+// unmarshalled with fallback.
+func unmarshalContent(data []byte, contentType, contentEncoding string, fallback Codec, v interface{}) error {
+	switch contentEncoding {
+	case "", "identity":
+	default:
+		e, ok := lookupEncoding(contentEncoding)
+		if !ok {
+			return fmt.Errorf("unsupported content encoding %q", contentEncoding)
+		}
+		var err error
+		if data, err = decode(e, bytes.NewReader(data)); err != nil {
+			return err
+		}
+	}
+	c, ok := lookupCodec(contentType)
+	if !ok {
+		c = baseCodec(fallback)
+	}
+	return c.Unmarshal(data, v)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string                        { return "application/json" }
+func (jsonCodec) ContentEncoding() string                    { return "" }
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+type yamlCodec struct{}
+
+func (yamlCodec) ContentType() string                        { return "application/x-yaml" }
+func (yamlCodec) ContentEncoding() string                    { return "" }
+func (yamlCodec) Marshal(v interface{}) ([]byte, error)      { return yaml.Marshal(v) }
+func (yamlCodec) Unmarshal(data []byte, v interface{}) error { return yaml.Unmarshal(data, v) }
+
+type gzipEncoding struct{}
+
+func (gzipEncoding) Name() string { return "gzip" }
+
+func (gzipEncoding) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+func (gzipEncoding) NewWriter(w io.Writer) io.WriteCloser {
+	return gzip.NewWriter(w)
+}
+
+type zstdEncoding struct{}
+
+func (zstdEncoding) Name() string { return "zstd" }
+
+func (zstdEncoding) NewReader(r io.Reader) (io.ReadCloser, error) {
+	d, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return d.IOReadCloser(), nil
+}
+
+func (zstdEncoding) NewWriter(w io.Writer) io.WriteCloser {
+	e, err := zstd.NewWriter(w)
+	if err != nil {
+		return errWriteCloser{err}
+	}
+	return e
+}
+
+// errWriteCloser fails every write with the error from creating an encoder.
+type errWriteCloser struct {
+	err error
+}
+
+func (w errWriteCloser) Write([]byte) (int, error) { return 0, w.err }
+func (w errWriteCloser) Close() error              { return w.err }
@@ -0,0 +1,199 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	// maxCopyObjectSize is the largest object AWS copies in a single CopyObject call.
+	// https://docs.aws.amazon.com/AmazonS3/latest/API/API_CopyObject.html
+	maxCopyObjectSize = 5 * 1024 * 1024 * 1024
+
+	// maxUploadParts is the maximum parts allowed by AWS in a multipart upload.
+	// https://docs.aws.amazon.com/AmazonS3/latest/userguide/qfacts.html
+	maxUploadParts = 10000
+
+	// copyPartSize is the smallest part size used for multipart copies.
+	copyPartSize = 512 * 1024 * 1024
+
+	// abortTimeout bounds the abort of a failed multipart copy.
+	abortTimeout = 30 * time.Second
+)
+
+// Copy copies the object at srcKey to dstKey in dstBucket.
+// See CopyWithContext.
+func (svc *Service) Copy(srcKey, dstBucket, dstKey string) (*s3.CopyObjectOutput, error) {
+	return svc.CopyWithContext(context.TODO(), srcKey, dstBucket, dstKey)
+}
+
+// CopyWithContext copies the object at srcKey to dstKey in dstBucket.
+// The copy is stored with the Service ACL, SSE and storage class settings, and
+// the tags of the source. Objects larger than
+// 5 GB are copied with a multipart upload, carrying over their content headers
+// and metadata as CopyObject would.
+func (svc *Service) CopyWithContext(ctx context.Context, srcKey, dstBucket, dstKey string) (*s3.CopyObjectOutput, error) {
+	return svc.copyVersion(ctx, srcKey, "", dstBucket, dstKey)
+}
+
+// Move moves the object at srcKey to dstKey in dstBucket.
+// See MoveWithContext.
+func (svc *Service) Move(srcKey, dstBucket, dstKey string) (*s3.CopyObjectOutput, error) {
+	return svc.MoveWithContext(context.TODO(), srcKey, dstBucket, dstKey)
+}
+
+// MoveWithContext copies the object at srcKey to dstKey in dstBucket, then
+// deletes the source. The source is left in place if the copy fails.
+func (svc *Service) MoveWithContext(ctx context.Context, srcKey, dstBucket, dstKey string) (*s3.CopyObjectOutput, error) {
+	if dstBucket == svc.name && dstKey == srcKey {
+		return nil, fmt.Errorf("move %s: source and destination are the same", srcKey)
+	}
+	out, err := svc.CopyWithContext(ctx, srcKey, dstBucket, dstKey)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := svc.DeleteWithContext(ctx, srcKey); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+// copyVersion copies a version of the object at srcKey, the current version
+// when versionID is empty, to dstKey in dstBucket.
+func (svc *Service) copyVersion(ctx context.Context, srcKey, versionID, dstBucket, dstKey string) (*s3.CopyObjectOutput, error) {
+	head := &s3.HeadObjectInput{
+		Bucket: aws.String(svc.name),
+		Key:    aws.String(srcKey),
+	}
+	if versionID != "" {
+		head.SetVersionId(versionID)
+	}
+	svc.sse.applyHead(head)
+	src, err := svc.svc.HeadObjectWithContext(ctx, head)
+	if err != nil {
+		return nil, err
+	}
+	source := copySource(svc.name, srcKey, versionID)
+	if aws.Int64Value(src.ContentLength) > maxCopyObjectSize {
+		out, err := svc.multipartCopy(ctx, srcKey, src, dstBucket, dstKey)
+		return out, svc.lockError(ctx, err, dstBucket, dstKey, "")
+	}
+	in := &s3.CopyObjectInput{
+		ACL:          svc.acl,
+		Bucket:       aws.String(dstBucket),
+		CopySource:   aws.String(source),
+		Key:          aws.String(dstKey),
+		StorageClass: svc.storageClass,
+	}
+	svc.sse.applyCopy(in)
+	out, err := svc.svc.CopyObjectWithContext(ctx, in)
+	return out, svc.lockError(ctx, err, dstBucket, dstKey, "")
+}
+
+// multipartCopy copies the version of the object at srcKey described by src in
+// parts. The upload is aborted if any part fails.
+func (svc *Service) multipartCopy(ctx context.Context, srcKey string, src *s3.HeadObjectOutput, dstBucket, dstKey string) (*s3.CopyObjectOutput, error) {
+	source := copySource(svc.name, srcKey, aws.StringValue(src.VersionId))
+	tags, err := svc.svc.GetObjectTaggingWithContext(ctx, &s3.GetObjectTaggingInput{
+		Bucket:    aws.String(svc.name),
+		Key:       aws.String(srcKey),
+		VersionId: src.VersionId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	create := &s3.CreateMultipartUploadInput{
+		ACL:                svc.acl,
+		Bucket:             aws.String(dstBucket),
+		CacheControl:       src.CacheControl,
+		ContentDisposition: src.ContentDisposition,
+		ContentEncoding:    src.ContentEncoding,
+		ContentLanguage:    src.ContentLanguage,
+		ContentType:        src.ContentType,
+		Key:                aws.String(dstKey),
+		Metadata:           src.Metadata,
+		StorageClass:       src.StorageClass,
+	}
+	if svc.storageClass != nil {
+		create.StorageClass = svc.storageClass
+	}
+	if len(tags.TagSet) > 0 {
+		v := url.Values{}
+		for _, tag := range tags.TagSet {
+			v.Set(aws.StringValue(tag.Key), aws.StringValue(tag.Value))
+		}
+		create.Tagging = aws.String(v.Encode())
+	}
+	svc.sse.applyCreateMultipart(create)
+	upload, err := svc.svc.CreateMultipartUploadWithContext(ctx, create)
+	if err != nil {
+		return nil, err
+	}
+	abort := func(err error) (*s3.CopyObjectOutput, error) {
+		// not ctx, so a cancelled copy is still cleaned up
+		actx, cancel := context.WithTimeout(context.Background(), abortTimeout)
+		defer cancel()
+		_, abortErr := svc.svc.AbortMultipartUploadWithContext(actx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(dstBucket),
+			Key:      aws.String(dstKey),
+			UploadId: upload.UploadId,
+		})
+		return nil, errors.Join(err, abortErr)
+	}
+
+	size := aws.Int64Value(src.ContentLength)
+	partSize := int64(copyPartSize)
+	if min := (size + maxUploadParts - 1) / maxUploadParts; min > partSize {
+		partSize = min
+	}
+	var parts []*s3.CompletedPart
+	for start, n := int64(0), int64(1); start < size; start, n = start+partSize, n+1 {
+		end := start + partSize - 1
+		if end >= size {
+			end = size - 1
+		}
+		part := &s3.UploadPartCopyInput{
+			Bucket:          aws.String(dstBucket),
+			CopySource:      aws.String(source),
+			CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+			Key:             aws.String(dstKey),
+			PartNumber:      aws.Int64(n),
+			UploadId:        upload.UploadId,
+		}
+		svc.sse.applyUploadPartCopy(part)
+		res, err := svc.svc.UploadPartCopyWithContext(ctx, part)
+		if err != nil {
+			return abort(err)
+		}
+		parts = append(parts, &s3.CompletedPart{
+			ETag:       res.CopyPartResult.ETag,
+			PartNumber: aws.Int64(n),
+		})
+	}
+
+	done, err := svc.svc.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(dstBucket),
+		Key:             aws.String(dstKey),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+		UploadId:        upload.UploadId,
+	})
+	if err != nil {
+		return abort(err)
+	}
+	return &s3.CopyObjectOutput{
+		BucketKeyEnabled:     done.BucketKeyEnabled,
+		CopyObjectResult:     &s3.CopyObjectResult{ETag: done.ETag},
+		CopySourceVersionId:  src.VersionId,
+		Expiration:           done.Expiration,
+		RequestCharged:       done.RequestCharged,
+		SSEKMSKeyId:          done.SSEKMSKeyId,
+		ServerSideEncryption: done.ServerSideEncryption,
+		VersionId:            done.VersionId,
+	}, nil
+}
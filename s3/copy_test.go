@@ -0,0 +1,184 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cleardataeng/aidews/s3/extmocks/github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+)
+
+func TestService_CopyWithContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+
+	s3Mock.EXPECT().HeadObjectWithContext(ctx, gomock.Any()).Return(&s3.HeadObjectOutput{ContentLength: aws.Int64(42)}, nil)
+	s3Mock.EXPECT().CopyObjectWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.CopyObjectInput, _ ...request.Option) (*s3.CopyObjectOutput, error) {
+			if aws.StringValue(in.ACL) != "bucket-owner-full-control" || aws.StringValue(in.ServerSideEncryption) != "AES256" {
+				t.Errorf("destination ACL and SSE not applied: %v", in)
+			}
+			if want := testBucket + "/keys/a%2Bb.txt"; aws.StringValue(in.CopySource) != want {
+				t.Errorf(`copy source: want: "%s", got: "%s"`, want, aws.StringValue(in.CopySource))
+			}
+			if aws.StringValue(in.Bucket) != "other-bucket" || aws.StringValue(in.Key) != "copy.txt" {
+				t.Errorf("unexpected destination: %s/%s", aws.StringValue(in.Bucket), aws.StringValue(in.Key))
+			}
+			return &s3.CopyObjectOutput{CopyObjectResult: &s3.CopyObjectResult{ETag: aws.String("etag")}}, nil
+		},
+	)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	out, err := svc.CopyWithContext(ctx, "keys/a+b.txt", "other-bucket", "copy.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.StringValue(out.CopyObjectResult.ETag) != "etag" {
+		t.Errorf("etag: want: etag, got: %s", aws.StringValue(out.CopyObjectResult.ETag))
+	}
+}
+
+func TestService_CopyWithContextMultipart(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+
+	size := int64(maxCopyObjectSize + copyPartSize/2)
+	s3Mock.EXPECT().HeadObjectWithContext(ctx, gomock.Any()).Return(&s3.HeadObjectOutput{
+		ContentLength: aws.Int64(size),
+		ContentType:   aws.String("text/csv"),
+	}, nil)
+	s3Mock.EXPECT().GetObjectTaggingWithContext(ctx, gomock.Any()).Return(&s3.GetObjectTaggingOutput{
+		TagSet: []*s3.Tag{{Key: aws.String("team"), Value: aws.String("data eng")}},
+	}, nil)
+	s3Mock.EXPECT().CreateMultipartUploadWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.CreateMultipartUploadInput, _ ...request.Option) (*s3.CreateMultipartUploadOutput, error) {
+			if aws.StringValue(in.ACL) != "bucket-owner-full-control" || aws.StringValue(in.ServerSideEncryption) != "AES256" {
+				t.Errorf("destination ACL and SSE not applied: %v", in)
+			}
+			if aws.StringValue(in.Tagging) != "team=data+eng" {
+				t.Errorf(`tagging: want: "team=data+eng", got: "%s"`, aws.StringValue(in.Tagging))
+			}
+			if aws.StringValue(in.ContentType) != "text/csv" {
+				t.Errorf(`content type: want: "text/csv", got: "%s"`, aws.StringValue(in.ContentType))
+			}
+			return &s3.CreateMultipartUploadOutput{UploadId: aws.String("up")}, nil
+		},
+	)
+	var ranges []string
+	s3Mock.EXPECT().UploadPartCopyWithContext(ctx, gomock.Any()).Times(11).DoAndReturn(
+		func(_ context.Context, in *s3.UploadPartCopyInput, _ ...request.Option) (*s3.UploadPartCopyOutput, error) {
+			ranges = append(ranges, aws.StringValue(in.CopySourceRange))
+			return &s3.UploadPartCopyOutput{CopyPartResult: &s3.CopyPartResult{ETag: aws.String("etag")}}, nil
+		},
+	)
+	s3Mock.EXPECT().CompleteMultipartUploadWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.CompleteMultipartUploadInput, _ ...request.Option) (*s3.CompleteMultipartUploadOutput, error) {
+			if len(in.MultipartUpload.Parts) != 11 {
+				t.Errorf("parts: want: 11, got: %d", len(in.MultipartUpload.Parts))
+			}
+			return &s3.CompleteMultipartUploadOutput{ETag: aws.String("done")}, nil
+		},
+	)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	out, err := svc.CopyWithContext(ctx, "big.csv", "other-bucket", "copy.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.StringValue(out.CopyObjectResult.ETag) != "done" {
+		t.Errorf("etag: want: done, got: %s", aws.StringValue(out.CopyObjectResult.ETag))
+	}
+	if want := fmt.Sprintf("bytes=%d-%d", 10*copyPartSize, size-1); ranges[10] != want {
+		t.Errorf(`last range: want: "%s", got: "%s"`, want, ranges[10])
+	}
+}
+
+func TestService_CopyWithContextMultipartAbort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s3Mock.EXPECT().HeadObjectWithContext(cctx, gomock.Any()).Return(&s3.HeadObjectOutput{
+		ContentLength: aws.Int64(maxCopyObjectSize + 1),
+	}, nil)
+	s3Mock.EXPECT().GetObjectTaggingWithContext(cctx, gomock.Any()).Return(&s3.GetObjectTaggingOutput{}, nil)
+	s3Mock.EXPECT().CreateMultipartUploadWithContext(cctx, gomock.Any()).Return(&s3.CreateMultipartUploadOutput{UploadId: aws.String("up")}, nil)
+	partErr := errors.New("part failed")
+	s3Mock.EXPECT().UploadPartCopyWithContext(cctx, gomock.Any()).DoAndReturn(
+		func(context.Context, *s3.UploadPartCopyInput, ...request.Option) (*s3.UploadPartCopyOutput, error) {
+			cancel()
+			return nil, partErr
+		},
+	)
+	abortErr := awserr.New("NoSuchUpload", "The specified upload does not exist.", nil)
+	s3Mock.EXPECT().AbortMultipartUploadWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(actx context.Context, in *s3.AbortMultipartUploadInput, _ ...request.Option) (*s3.AbortMultipartUploadOutput, error) {
+			if actx.Err() != nil {
+				t.Error("abort used the cancelled context of the copy")
+			}
+			if _, ok := actx.Deadline(); !ok {
+				t.Error("abort has no deadline")
+			}
+			if aws.StringValue(in.UploadId) != "up" {
+				t.Errorf(`upload id: want: "up", got: "%s"`, aws.StringValue(in.UploadId))
+			}
+			return nil, abortErr
+		},
+	)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	_, err := svc.CopyWithContext(cctx, "big.csv", "other-bucket", "copy.csv")
+	if !errors.Is(err, partErr) || !errors.Is(err, abortErr) {
+		t.Errorf("want the part and abort errors, got: %v", err)
+	}
+}
+
+func TestService_MoveWithContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+
+	gomock.InOrder(
+		s3Mock.EXPECT().HeadObjectWithContext(ctx, gomock.Any()).Return(&s3.HeadObjectOutput{ContentLength: aws.Int64(42)}, nil),
+		s3Mock.EXPECT().CopyObjectWithContext(ctx, gomock.Any()).Return(&s3.CopyObjectOutput{}, nil),
+		s3Mock.EXPECT().DeleteObjectWithContext(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, in *s3.DeleteObjectInput, _ ...request.Option) (*s3.DeleteObjectOutput, error) {
+				if aws.StringValue(in.Bucket) != testBucket || aws.StringValue(in.Key) != "a.txt" {
+					t.Errorf("deleted %s/%s, want the source", aws.StringValue(in.Bucket), aws.StringValue(in.Key))
+				}
+				return &s3.DeleteObjectOutput{}, nil
+			},
+		),
+	)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	if _, err := svc.MoveWithContext(ctx, "a.txt", "other-bucket", "b.txt"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_MoveWithContextCopyFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+
+	copyErr := awserr.New("AccessDenied", "Access Denied", nil)
+	s3Mock.EXPECT().HeadObjectWithContext(ctx, gomock.Any()).Return(&s3.HeadObjectOutput{ContentLength: aws.Int64(42)}, nil)
+	s3Mock.EXPECT().CopyObjectWithContext(ctx, gomock.Any()).Return(nil, copyErr)
+	s3Mock.EXPECT().DeleteObjectWithContext(gomock.Any(), gomock.Any()).Times(0)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	if _, err := svc.MoveWithContext(ctx, "a.txt", "other-bucket", "b.txt"); !errors.Is(err, copyErr) {
+		t.Errorf("want the copy error, got: %v", err)
+	}
+}
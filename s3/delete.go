@@ -0,0 +1,159 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// maxDeleteObjects is the maximum keys allowed by AWS in a DeleteObjects call.
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteObjects.html
+const maxDeleteObjects = 1000
+
+// ObjectError is the failure of a batch operation on a single object.
+type ObjectError struct {
+	Key     string
+	Code    string
+	Message string
+}
+
+func (e *ObjectError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.Key, e.Code, e.Message)
+}
+
+// Is reports whether target is ErrObjectLocked and object lock blocked the
+// operation on the object.
+func (e *ObjectError) Is(target error) bool {
+	return target == ErrObjectLocked && isObjectLockDenial(e.Code, e.Message)
+}
+
+// BatchError collects the objects that failed in a batch operation.
+type BatchError []*ObjectError
+
+func (e BatchError) Error() string {
+	msgs := make([]string, len(e))
+	for i, oe := range e {
+		msgs[i] = oe.Error()
+	}
+	return fmt.Sprintf("%d objects failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Delete deletes the object from the bucket at the key.
+func (svc *Service) Delete(key string) (*s3.DeleteObjectOutput, error) {
+	return svc.DeleteWithContext(context.TODO(), key)
+}
+
+// DeleteWithContext deletes the object from the bucket at the key.
+func (svc *Service) DeleteWithContext(ctx context.Context, key string) (*s3.DeleteObjectOutput, error) {
+	in := &s3.DeleteObjectInput{
+		Bucket: aws.String(svc.name),
+		Key:    aws.String(key),
+	}
+	res, err := svc.svc.DeleteObjectWithContext(ctx, in)
+	return res, svc.lockError(ctx, err, svc.name, key, "")
+}
+
+// DeleteMany deletes the objects at the keys.
+// See DeleteManyWithContext.
+func (svc *Service) DeleteMany(keys []string) error {
+	return svc.DeleteManyWithContext(context.TODO(), keys)
+}
+
+// DeleteManyWithContext deletes the objects at the keys, in batches of 1000.
+// Keys S3 fails to delete are reported in a BatchError, after every batch has
+// been attempted. Any other error stops the deletion.
+func (svc *Service) DeleteManyWithContext(ctx context.Context, keys []string) error {
+	var failed BatchError
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > maxDeleteObjects {
+			n = maxDeleteObjects
+		}
+		errs, err := svc.deleteBatch(ctx, keys[:n])
+		if err != nil {
+			return err
+		}
+		failed = append(failed, errs...)
+		keys = keys[n:]
+	}
+	if len(failed) > 0 {
+		return failed
+	}
+	return nil
+}
+
+// DeletePrefix deletes every object under prefix.
+// See DeletePrefixWithContext.
+func (svc *Service) DeletePrefix(prefix string) error {
+	return svc.DeletePrefixWithContext(context.TODO(), prefix)
+}
+
+// DeletePrefixWithContext deletes every object under prefix.
+// Objects are deleted in batches as the listing proceeds. Keys S3 fails to
+// delete are reported in a BatchError.
+func (svc *Service) DeletePrefixWithContext(ctx context.Context, prefix string) error {
+	var failed BatchError
+	var batchErr error
+	keys := make([]string, 0, maxDeleteObjects)
+	flush := func() bool {
+		errs, err := svc.deleteBatch(ctx, keys)
+		if err != nil {
+			batchErr = err
+			return false
+		}
+		failed = append(failed, errs...)
+		keys = keys[:0]
+		return true
+	}
+	err := svc.ListWithContext(ctx, &ListInput{Prefix: prefix}, func(obj ObjectSummary) bool {
+		keys = append(keys, obj.Key)
+		if len(keys) < maxDeleteObjects {
+			return true
+		}
+		return flush()
+	})
+	if err != nil {
+		return err
+	}
+	if batchErr != nil {
+		return batchErr
+	}
+	if len(keys) > 0 && !flush() {
+		return batchErr
+	}
+	if len(failed) > 0 {
+		return failed
+	}
+	return nil
+}
+
+// deleteBatch deletes up to maxDeleteObjects keys and returns the keys that failed.
+func (svc *Service) deleteBatch(ctx context.Context, keys []string) (BatchError, error) {
+	objs := make([]*s3.ObjectIdentifier, len(keys))
+	for i, key := range keys {
+		objs[i] = &s3.ObjectIdentifier{Key: aws.String(key)}
+	}
+	in := &s3.DeleteObjectsInput{
+		Bucket: aws.String(svc.name),
+		Delete: &s3.Delete{
+			Objects: objs,
+			Quiet:   aws.Bool(true),
+		},
+	}
+	res, err := svc.svc.DeleteObjectsWithContext(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	var failed BatchError
+	for _, e := range res.Errors {
+		failed = append(failed, &ObjectError{
+			Key:     aws.StringValue(e.Key),
+			Code:    aws.StringValue(e.Code),
+			Message: aws.StringValue(e.Message),
+		})
+	}
+	return failed, nil
+}
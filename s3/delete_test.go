@@ -0,0 +1,95 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cleardataeng/aidews/s3/extmocks/github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+)
+
+func TestService_DeleteManyWithContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+
+	keys := make([]string, 2500)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%04d", i)
+	}
+	var sizes []int
+	s3Mock.EXPECT().DeleteObjectsWithContext(ctx, gomock.Any()).Times(3).DoAndReturn(
+		func(_ context.Context, in *s3.DeleteObjectsInput, _ ...request.Option) (*s3.DeleteObjectsOutput, error) {
+			sizes = append(sizes, len(in.Delete.Objects))
+			first := in.Delete.Objects[0].Key
+			return &s3.DeleteObjectsOutput{
+				Errors: []*s3.Error{{Key: first, Code: aws.String("AccessDenied"), Message: aws.String("Access Denied")}},
+			}, nil
+		},
+	)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	err := svc.DeleteManyWithContext(ctx, keys)
+	if fmt.Sprint(sizes) != "[1000 1000 500]" {
+		t.Errorf("batch sizes: want: [1000 1000 500], got: %v", sizes)
+	}
+	var batchErr BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("want BatchError, got: %v", err)
+	}
+	if len(batchErr) != 3 || batchErr[1].Key != "key-1000" || batchErr[1].Code != "AccessDenied" {
+		t.Errorf("unexpected per-key errors: %v", batchErr)
+	}
+}
+
+func TestService_DeletePrefixWithContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+
+	page := func(from, to int) *s3.ListObjectsV2Output {
+		out := &s3.ListObjectsV2Output{}
+		for i := from; i < to; i++ {
+			out.Contents = append(out.Contents, &s3.Object{Key: aws.String(fmt.Sprintf("logs/%04d", i))})
+		}
+		return out
+	}
+	s3Mock.EXPECT().ListObjectsV2PagesWithContext(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.ListObjectsV2Input, f func(*s3.ListObjectsV2Output, bool) bool, _ ...request.Option) error {
+			if got := aws.StringValue(in.Prefix); got != "logs/" {
+				t.Errorf(`prefix: want: "logs/", got: "%s"`, got)
+			}
+			if f(page(0, 600), false) {
+				f(page(600, 1200), true)
+			}
+			return nil
+		},
+	)
+	var batches [][]string
+	s3Mock.EXPECT().DeleteObjectsWithContext(ctx, gomock.Any()).Times(2).DoAndReturn(
+		func(_ context.Context, in *s3.DeleteObjectsInput, _ ...request.Option) (*s3.DeleteObjectsOutput, error) {
+			var keys []string
+			for _, obj := range in.Delete.Objects {
+				keys = append(keys, aws.StringValue(obj.Key))
+			}
+			batches = append(batches, keys)
+			return &s3.DeleteObjectsOutput{}, nil
+		},
+	)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	if err := svc.DeletePrefixWithContext(ctx, "logs/"); err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 2 || len(batches[0]) != 1000 || len(batches[1]) != 200 {
+		t.Fatalf("batches: want: 1000 and 200 keys, got: %d", len(batches))
+	}
+	if batches[0][0] != "logs/0000" || batches[1][199] != "logs/1199" {
+		t.Errorf("unexpected keys: %s, %s", batches[0][0], batches[1][199])
+	}
+}
@@ -0,0 +1,127 @@
+package s3
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// Server side encryption algorithms.
+const (
+	SSEAES256 = s3.ServerSideEncryptionAes256
+	SSEKMS    = s3.ServerSideEncryptionAwsKms
+)
+
+// Encryption is the server side encryption applied to objects written, and
+// the customer key used for objects read, by a Service.
+type Encryption struct {
+	// Algorithm is SSEAES256 or SSEKMS. It is ignored when CustomerKey is set.
+	Algorithm string
+
+	// KMSKeyID is the ID, ARN or alias of the KMS key used with SSEKMS.
+	// The AWS managed key is used when empty.
+	KMSKeyID string
+
+	// KMSContext is the encryption context used with SSEKMS.
+	KMSContext map[string]string
+
+	// BucketKey enables an S3 Bucket Key with SSEKMS.
+	BucketKey bool
+
+	// CustomerKey is a 256-bit key provided for SSE-C. S3 does not store the
+	// key, so the same key must be given to read the objects back.
+	CustomerKey []byte
+}
+
+// sseHeaders are the request settings for an Encryption.
+type sseHeaders struct {
+	algorithm, kmsKeyID, kmsContext *string
+	bucketKey                       *bool
+	customerAlgorithm, customerKey  *string
+}
+
+// headers returns the request settings for e, which may be nil for none.
+func (e *Encryption) headers() sseHeaders {
+	var h sseHeaders
+	switch {
+	case e == nil:
+	case len(e.CustomerKey) > 0:
+		h.customerAlgorithm = aws.String(SSEAES256)
+		h.customerKey = aws.String(string(e.CustomerKey))
+	case e.Algorithm != "":
+		h.algorithm = aws.String(e.Algorithm)
+		if e.Algorithm != SSEKMS {
+			break
+		}
+		if e.KMSKeyID != "" {
+			h.kmsKeyID = aws.String(e.KMSKeyID)
+		}
+		if len(e.KMSContext) > 0 {
+			data, _ := json.Marshal(e.KMSContext) // a map of strings always marshals
+			h.kmsContext = aws.String(base64.StdEncoding.EncodeToString(data))
+		}
+		if e.BucketKey {
+			h.bucketKey = aws.Bool(true)
+		}
+	}
+	return h
+}
+
+func (e *Encryption) applyPut(in *s3.PutObjectInput) {
+	h := e.headers()
+	in.ServerSideEncryption, in.SSEKMSKeyId, in.SSEKMSEncryptionContext = h.algorithm, h.kmsKeyID, h.kmsContext
+	in.BucketKeyEnabled = h.bucketKey
+	in.SSECustomerAlgorithm, in.SSECustomerKey = h.customerAlgorithm, h.customerKey
+}
+
+func (e *Encryption) applyUpload(in *s3manager.UploadInput) {
+	h := e.headers()
+	in.ServerSideEncryption, in.SSEKMSKeyId, in.SSEKMSEncryptionContext = h.algorithm, h.kmsKeyID, h.kmsContext
+	in.BucketKeyEnabled = h.bucketKey
+	in.SSECustomerAlgorithm, in.SSECustomerKey = h.customerAlgorithm, h.customerKey
+}
+
+func (e *Encryption) applyCreateMultipart(in *s3.CreateMultipartUploadInput) {
+	h := e.headers()
+	in.ServerSideEncryption, in.SSEKMSKeyId, in.SSEKMSEncryptionContext = h.algorithm, h.kmsKeyID, h.kmsContext
+	in.BucketKeyEnabled = h.bucketKey
+	in.SSECustomerAlgorithm, in.SSECustomerKey = h.customerAlgorithm, h.customerKey
+}
+
+// applyCopy sets the encryption of the destination and, for SSE-C, the
+// customer key of the source, which was written by the same Service.
+func (e *Encryption) applyCopy(in *s3.CopyObjectInput) {
+	h := e.headers()
+	in.ServerSideEncryption, in.SSEKMSKeyId, in.SSEKMSEncryptionContext = h.algorithm, h.kmsKeyID, h.kmsContext
+	in.BucketKeyEnabled = h.bucketKey
+	in.SSECustomerAlgorithm, in.SSECustomerKey = h.customerAlgorithm, h.customerKey
+	in.CopySourceSSECustomerAlgorithm, in.CopySourceSSECustomerKey = h.customerAlgorithm, h.customerKey
+}
+
+// applyUploadPartCopy sets the customer key of both the part and its source.
+// Other encryption settings are taken from the multipart upload.
+func (e *Encryption) applyUploadPartCopy(in *s3.UploadPartCopyInput) {
+	h := e.headers()
+	in.SSECustomerAlgorithm, in.SSECustomerKey = h.customerAlgorithm, h.customerKey
+	in.CopySourceSSECustomerAlgorithm, in.CopySourceSSECustomerKey = h.customerAlgorithm, h.customerKey
+}
+
+// applyGet sets the customer key needed to read SSE-C objects. Objects
+// encrypted by S3 are decrypted without any request settings.
+func (e *Encryption) applyGet(in *s3.GetObjectInput) {
+	h := e.headers()
+	in.SSECustomerAlgorithm, in.SSECustomerKey = h.customerAlgorithm, h.customerKey
+}
+
+func (e *Encryption) applyHead(in *s3.HeadObjectInput) {
+	h := e.headers()
+	in.SSECustomerAlgorithm, in.SSECustomerKey = h.customerAlgorithm, h.customerKey
+}
+
+func (e *Encryption) applySelect(in *s3.SelectObjectContentInput) {
+	h := e.headers()
+	in.SSECustomerAlgorithm, in.SSECustomerKey = h.customerAlgorithm, h.customerKey
+}
@@ -0,0 +1,88 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cleardataeng/aidews/s3/extmocks/github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+)
+
+func TestService_PutKMS(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	s3Mock.EXPECT().PutObject(gomock.Any()).DoAndReturn(
+		func(in *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+			if got := aws.StringValue(in.ServerSideEncryption); got != SSEKMS {
+				t.Errorf(`sse: want: "%s", got: "%s"`, SSEKMS, got)
+			}
+			if got := aws.StringValue(in.SSEKMSKeyId); got != "alias/records" {
+				t.Errorf(`kms key: want: "alias/records", got: "%s"`, got)
+			}
+			ctx, _ := base64.StdEncoding.DecodeString(aws.StringValue(in.SSEKMSEncryptionContext))
+			if string(ctx) != `{"tier":"compliance"}` {
+				t.Errorf(`kms context: want: {"tier":"compliance"}, got: %s`, ctx)
+			}
+			if !aws.BoolValue(in.BucketKeyEnabled) {
+				t.Error("bucket key not enabled")
+			}
+			return &s3.PutObjectOutput{}, nil
+		},
+	)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	svc.SetEncryption(&Encryption{
+		Algorithm:  SSEKMS,
+		KMSKeyID:   "alias/records",
+		KMSContext: map[string]string{"tier": "compliance"},
+		BucketKey:  true,
+	})
+	if _, err := svc.Put("doc", strings.NewReader("{}")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_CustomerKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	key := bytes.Repeat([]byte{7}, 32)
+
+	s3Mock.EXPECT().GetObjectWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.GetObjectInput, _ ...request.Option) (*s3.GetObjectOutput, error) {
+			if aws.StringValue(in.SSECustomerKey) != string(key) || aws.StringValue(in.SSECustomerAlgorithm) != SSEAES256 {
+				t.Error("customer key not set on read")
+			}
+			return &s3.GetObjectOutput{Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+		},
+	)
+	s3Mock.EXPECT().HeadObjectWithContext(ctx, gomock.Any()).Return(&s3.HeadObjectOutput{ContentLength: aws.Int64(1)}, nil)
+	s3Mock.EXPECT().CopyObjectWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.CopyObjectInput, _ ...request.Option) (*s3.CopyObjectOutput, error) {
+			if aws.StringValue(in.SSECustomerKey) != string(key) || aws.StringValue(in.CopySourceSSECustomerKey) != string(key) {
+				t.Error("customer key not set on copy source and destination")
+			}
+			if in.ServerSideEncryption != nil {
+				t.Errorf("sse: want: nil, got: %s", aws.StringValue(in.ServerSideEncryption))
+			}
+			return &s3.CopyObjectOutput{}, nil
+		},
+	)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	svc.SetEncryption(&Encryption{CustomerKey: key})
+	if _, err := svc.Read("doc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.CopyWithContext(ctx, "doc", testBucket, "copy"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,200 @@
+package s3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// EventRecord is an S3 event notification about one object.
+type EventRecord struct {
+	// EventName is the event type, e.g. "ObjectCreated:Put", or the detail type,
+	// e.g. "Object Created", for events delivered by EventBridge.
+	EventName string
+	EventTime time.Time
+	Region    string
+	Bucket    string
+
+	// Key is the object key, unescaped from the URL encoding of notifications.
+	Key string
+
+	Size      int64
+	ETag      string
+	VersionID string
+	Sequencer string
+}
+
+// Created reports whether the event is for an object created, rather than
+// removed, restored or otherwise changed.
+func (r EventRecord) Created() bool {
+	return strings.HasPrefix(r.EventName, "ObjectCreated:") || r.EventName == "Object Created"
+}
+
+// s3Event is an S3 event notification, and the envelopes it may be delivered
+// in: SNS and SQS Lambda events, SNS messages delivered to SQS, and EventBridge.
+type s3Event struct {
+	Records []struct {
+		EventSource    string `json:"eventSource"`
+		SNSEventSource string `json:"EventSource"`
+		EventName      string `json:"eventName"`
+		EventTime      time.Time
+		AWSRegion      string `json:"awsRegion"`
+		S3             *s3EventEntity
+		SNS            *struct{ Message string } `json:"Sns"`
+		Body           string                    `json:"body"`
+	}
+
+	// an SNS message, as delivered to SQS
+	Type    string
+	Message string
+
+	// an EventBridge event
+	Source     string          `json:"source"`
+	DetailType string          `json:"detail-type"`
+	Time       time.Time       `json:"time"`
+	Region     string          `json:"region"`
+	Detail     *s3EventBridged `json:"detail"`
+}
+
+type s3EventEntity struct {
+	Bucket struct {
+		Name string `json:"name"`
+	} `json:"bucket"`
+	Object struct {
+		Key       string `json:"key"`
+		Size      int64  `json:"size"`
+		ETag      string `json:"eTag"`
+		VersionID string `json:"versionId"`
+		Sequencer string `json:"sequencer"`
+	} `json:"object"`
+}
+
+type s3EventBridged struct {
+	Bucket struct {
+		Name string `json:"name"`
+	} `json:"bucket"`
+	Object struct {
+		Key       string `json:"key"`
+		Size      int64  `json:"size"`
+		ETag      string `json:"etag"`
+		VersionID string `json:"version-id"`
+		Sequencer string `json:"sequencer"`
+	} `json:"object"`
+}
+
+// ParseEvent returns the object records of an S3 event notification, whether
+// delivered directly, in an SNS or SQS event, as an SNS message in an SQS
+// event, or by EventBridge. Records of other sources, and test events, are
+// skipped.
+func ParseEvent(data []byte) ([]EventRecord, error) {
+	var ev s3Event
+	if err := json.Unmarshal(data, &ev); err != nil {
+		return nil, fmt.Errorf("s3 event: %w", err)
+	}
+	switch {
+	case ev.Type == "Notification":
+		return ParseEvent([]byte(ev.Message))
+	case ev.Source == "aws.s3" && ev.Detail != nil:
+		key, err := unescapeKey(ev.Detail.Object.Key)
+		if err != nil {
+			return nil, err
+		}
+		o := ev.Detail.Object
+		return []EventRecord{{
+			EventName: ev.DetailType,
+			EventTime: ev.Time,
+			Region:    ev.Region,
+			Bucket:    ev.Detail.Bucket.Name,
+			Key:       key,
+			Size:      o.Size,
+			ETag:      o.ETag,
+			VersionID: o.VersionID,
+			Sequencer: o.Sequencer,
+		}}, nil
+	}
+	var records []EventRecord
+	for _, r := range ev.Records {
+		var nested string
+		switch {
+		case r.EventSource == "aws:s3" && r.S3 != nil:
+			key, err := unescapeKey(r.S3.Object.Key)
+			if err != nil {
+				return nil, err
+			}
+			o := r.S3.Object
+			records = append(records, EventRecord{
+				EventName: r.EventName,
+				EventTime: r.EventTime,
+				Region:    r.AWSRegion,
+				Bucket:    r.S3.Bucket.Name,
+				Key:       key,
+				Size:      o.Size,
+				ETag:      o.ETag,
+				VersionID: o.VersionID,
+				Sequencer: o.Sequencer,
+			})
+			continue
+		case r.SNSEventSource == "aws:sns" && r.SNS != nil:
+			nested = r.SNS.Message
+		case r.EventSource == "aws:sqs":
+			nested = r.Body
+		default:
+			continue
+		}
+		inner, err := ParseEvent([]byte(nested))
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, inner...)
+	}
+	return records, nil
+}
+
+// unescapeKey decodes an object key from the form encoding of notifications,
+// in which spaces are "+" and other special characters are %-escaped.
+func unescapeKey(key string) (string, error) {
+	k, err := url.QueryUnescape(key)
+	if err != nil {
+		return "", fmt.Errorf("s3 event key %q: %w", key, err)
+	}
+	return k, nil
+}
+
+// ReadEvent gets the object of each created record and calls fn with its
+// content, stopping at the first error. See ReadEventWithContext.
+func (svc *Service) ReadEvent(records []EventRecord, fn func(EventRecord, io.Reader) error) error {
+	return svc.ReadEventWithContext(context.TODO(), records, fn)
+}
+
+// ReadEventWithContext gets the object of each created record and calls fn
+// with its content, stopping at the first error. Records for other buckets are
+// read with the settings and client of the Service, and removal and other
+// events are skipped. The version of the record is read when it has one, so a
+// later overwrite is not read in its place.
+func (svc *Service) ReadEventWithContext(ctx context.Context, records []EventRecord, fn func(EventRecord, io.Reader) error) error {
+	for _, r := range records {
+		if !r.Created() {
+			continue
+		}
+		if r.Bucket == "" || r.Key == "" {
+			return errors.New("s3 event: record without bucket or key")
+		}
+		bucket := *svc
+		bucket.name = r.Bucket
+		res, err := bucket.getObjectVersion(ctx, r.Key, r.VersionID)
+		if err != nil {
+			return fmt.Errorf("s3 event %s/%s: %w", r.Bucket, r.Key, err)
+		}
+		err = fn(r, res.Body)
+		res.Body.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
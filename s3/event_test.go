@@ -0,0 +1,131 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cleardataeng/aidews/s3/extmocks/github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+)
+
+const directEvent = `{"Records":[{
+	"eventVersion":"2.1","eventSource":"aws:s3","awsRegion":"us-west-2",
+	"eventTime":"2023-01-02T03:04:05.000Z","eventName":"ObjectCreated:Put",
+	"s3":{"bucket":{"name":"movement-keys"},"object":{"key":"reports/Q1+summary%2B%281%29.csv","size":42,"eTag":"abc","versionId":"v1","sequencer":"0055"}}
+}]}`
+
+var directRecord = EventRecord{
+	EventName: "ObjectCreated:Put",
+	EventTime: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+	Region:    "us-west-2",
+	Bucket:    "movement-keys",
+	Key:       "reports/Q1 summary+(1).csv",
+	Size:      42,
+	ETag:      "abc",
+	VersionID: "v1",
+	Sequencer: "0055",
+}
+
+func quote(t *testing.T, s string) string {
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestParseEvent(t *testing.T) {
+	snsMessage := `{"Type":"Notification","MessageId":"m1","Message":` + quote(t, directEvent) + `}`
+	tests := map[string]struct {
+		event string
+		want  []EventRecord
+	}{
+		"direct": {event: directEvent, want: []EventRecord{directRecord}},
+		"sns": {
+			event: `{"Records":[{"EventSource":"aws:sns","Sns":{"Type":"Notification","Message":` + quote(t, directEvent) + `}}]}`,
+			want:  []EventRecord{directRecord},
+		},
+		"sqs": {
+			event: `{"Records":[{"eventSource":"aws:sqs","body":` + quote(t, directEvent) + `}]}`,
+			want:  []EventRecord{directRecord},
+		},
+		"sns to sqs": {
+			event: `{"Records":[{"eventSource":"aws:sqs","body":` + quote(t, snsMessage) + `}]}`,
+			want:  []EventRecord{directRecord},
+		},
+		"eventbridge": {
+			event: `{"version":"0","source":"aws.s3","detail-type":"Object Created","time":"2023-01-02T03:04:05Z","region":"us-west-2",
+				"detail":{"bucket":{"name":"movement-keys"},"object":{"key":"reports/Q1+summary%2B%281%29.csv","size":42,"etag":"abc","version-id":"v1","sequencer":"0055"}}}`,
+			want: []EventRecord{{
+				EventName: "Object Created",
+				EventTime: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+				Region:    "us-west-2",
+				Bucket:    "movement-keys",
+				Key:       "reports/Q1 summary+(1).csv",
+				Size:      42,
+				ETag:      "abc",
+				VersionID: "v1",
+				Sequencer: "0055",
+			}},
+		},
+		"test event": {event: `{"Service":"Amazon S3","Event":"s3:TestEvent","Bucket":"movement-keys"}`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseEvent([]byte(tt.event))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(`records: want: %+v, got: %+v`, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestService_ReadEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	s3Mock.EXPECT().GetObjectWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.GetObjectInput, _ ...request.Option) (*s3.GetObjectOutput, error) {
+			if got := aws.StringValue(in.Bucket); got != "other-bucket" {
+				t.Errorf(`bucket: want: "other-bucket", got: "%s"`, got)
+			}
+			if got := aws.StringValue(in.Key); got != directRecord.Key {
+				t.Errorf(`key: want: "%s", got: "%s"`, directRecord.Key, got)
+			}
+			if got := aws.StringValue(in.VersionId); got != "v1" {
+				t.Errorf(`version: want: "v1", got: "%s"`, got)
+			}
+			return &s3.GetObjectOutput{Body: ioutil.NopCloser(bytes.NewReader([]byte("content")))}, nil
+		},
+	)
+
+	created := directRecord
+	created.Bucket = "other-bucket"
+	removed := directRecord
+	removed.EventName = "ObjectRemoved:Delete"
+	svc := newWithSvc(testBucket, s3Mock)
+	var read []string
+	err := svc.ReadEventWithContext(ctx, []EventRecord{created, removed}, func(r EventRecord, content io.Reader) error {
+		b, err := ioutil.ReadAll(content)
+		read = append(read, r.Key+"="+string(b))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{directRecord.Key + "=content"}; !reflect.DeepEqual(read, want) {
+		t.Errorf(`read: want: %v, got: %v`, want, read)
+	}
+}
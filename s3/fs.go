@@ -0,0 +1,273 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// BucketFS is a read-only file system of the objects under a bucket prefix.
+// Directories are the common prefixes of "/" delimited keys.
+type BucketFS struct {
+	prefix string
+	svc    *Service
+}
+
+var (
+	_ fs.FS        = (*BucketFS)(nil)
+	_ fs.ReadDirFS = (*BucketFS)(nil)
+	_ fs.StatFS    = (*BucketFS)(nil)
+	_ io.Seeker    = (*bucketFile)(nil)
+)
+
+// FS returns a read-only file system of the objects under prefix, for use
+// with template.ParseFS, http.FS and other io/fs consumers. File names are
+// the keys relative to prefix, which is treated as a directory.
+func (svc *Service) FS(prefix string) *BucketFS {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &BucketFS{prefix: prefix, svc: svc}
+}
+
+// Open opens the named file or directory. A file's content is got when it
+// is first read, and again from the new offset after a Seek, so files
+// support http.FS; they should be closed when done.
+func (fsys *BucketFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name != "." {
+		info, err := fsys.svc.HeadWithContext(context.TODO(), fsys.prefix+name)
+		if err == nil {
+			return &bucketFile{
+				svc:       fsys.svc,
+				key:       fsys.prefix + name,
+				versionID: info.VersionID,
+				etag:      info.ETag,
+				info:      &objectFileInfo{name: path.Base(name), size: info.Size, modTime: info.LastModified},
+			}, nil
+		}
+		if !isNotFound(err) {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+	}
+	entries, err := fsys.readDir("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return &bucketDir{info: dirFileInfo(name), entries: entries}, nil
+}
+
+// ReadDir reads the named directory and returns its entries sorted by name.
+func (fsys *BucketFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	return fsys.readDir("readdir", name)
+}
+
+// Stat returns the FileInfo of the named file or directory.
+func (fsys *BucketFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return dirFileInfo(name), nil
+	}
+	info, err := fsys.svc.HeadWithContext(context.TODO(), fsys.prefix+name)
+	if err == nil {
+		return &objectFileInfo{name: path.Base(name), size: info.Size, modTime: info.LastModified}, nil
+	}
+	if !isNotFound(err) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	// a directory exists when any key has it as a prefix
+	found := false
+	err = fsys.svc.ListWithContext(context.TODO(), &ListInput{Prefix: fsys.prefix + name + "/", PageSize: 1}, func(ObjectSummary) bool {
+		found = true
+		return false
+	})
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return dirFileInfo(name), nil
+}
+
+// readDir lists the entries of the named directory. Directories other than
+// the root must contain at least one object to exist.
+func (fsys *BucketFS) readDir(op, name string) ([]fs.DirEntry, error) {
+	dir := fsys.prefix
+	if name != "." {
+		dir += name + "/"
+	}
+	var entries []fs.DirEntry
+	err := fsys.svc.ListDirWithContext(context.TODO(), dir, func(obj ObjectSummary) bool {
+		base := strings.TrimSuffix(strings.TrimPrefix(obj.Key, dir), "/")
+		if base == "" {
+			return true // placeholder object for the directory itself
+		}
+		if obj.IsPrefix {
+			entries = append(entries, fs.FileInfoToDirEntry(dirFileInfo(base)))
+		} else {
+			entries = append(entries, fs.FileInfoToDirEntry(&objectFileInfo{name: base, size: obj.Size, modTime: obj.LastModified}))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	if len(entries) == 0 && name != "." {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// isNotFound reports whether err is S3 reporting a missing object.
+func isNotFound(err error) bool {
+	return hasErrorCode(err, "NoSuchKey", "NotFound")
+}
+
+// bucketFile is an object opened by BucketFS. Its content is read from the
+// offset with a ranged GetObject of the version, or ETag, that was opened,
+// so a file changed meanwhile fails to read rather than mixing versions.
+type bucketFile struct {
+	svc       *Service
+	key       string
+	versionID string
+	etag      string
+	info      *objectFileInfo
+	body      io.ReadCloser
+	offset    int64
+}
+
+func (f *bucketFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *bucketFile) Read(p []byte) (int, error) {
+	if f.offset >= f.info.size {
+		return 0, io.EOF
+	}
+	if f.body == nil {
+		in := &s3.GetObjectInput{
+			Bucket: aws.String(f.svc.name),
+			Key:    aws.String(f.key),
+			Range:  aws.String(fmt.Sprintf("bytes=%d-", f.offset)),
+		}
+		if f.versionID != "" {
+			in.SetVersionId(f.versionID)
+		} else if f.etag != "" {
+			in.SetIfMatch(`"` + f.etag + `"`)
+		}
+		f.svc.sse.applyGet(in)
+		res, err := f.svc.svc.GetObjectWithContext(context.TODO(), in)
+		if err != nil {
+			return 0, &fs.PathError{Op: "read", Path: f.info.name, Err: err}
+		}
+		f.body = res.Body
+	}
+	n, err := f.body.Read(p)
+	f.offset += int64(n)
+	return n, err
+}
+
+// Seek sets the offset of the next Read. The content is got again from the
+// new offset, unless it is unchanged.
+func (f *bucketFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.size
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.info.name, Err: fs.ErrInvalid}
+	}
+	if offset != f.offset && f.body != nil {
+		f.body.Close()
+		f.body = nil
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *bucketFile) Close() error {
+	if f.body == nil {
+		return nil
+	}
+	err := f.body.Close()
+	f.body = nil
+	return err
+}
+
+type bucketDir struct {
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *bucketDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *bucketDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: errors.New("is a directory")}
+}
+
+func (d *bucketDir) Close() error {
+	return nil
+}
+
+func (d *bucketDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
+
+type objectFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func dirFileInfo(name string) *objectFileInfo {
+	return &objectFileInfo{name: path.Base(name), dir: true}
+}
+
+func (fi *objectFileInfo) Name() string       { return fi.name }
+func (fi *objectFileInfo) Size() int64        { return fi.size }
+func (fi *objectFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *objectFileInfo) IsDir() bool        { return fi.dir }
+func (fi *objectFileInfo) Sys() interface{}   { return nil }
+
+func (fi *objectFileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
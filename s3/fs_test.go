@@ -0,0 +1,150 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cleardataeng/aidews/s3/extmocks/github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+)
+
+// expectBucket serves the objects from the mock for reads, heads and listings.
+func expectBucket(s3Mock *mock_s3iface.MockS3API, objects map[string]string) {
+	s3Mock.EXPECT().GetObjectWithContext(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(_ context.Context, in *s3.GetObjectInput, _ ...request.Option) (*s3.GetObjectOutput, error) {
+			content, ok := objects[*in.Key]
+			if !ok {
+				return nil, awserr.New(s3.ErrCodeNoSuchKey, "not found", nil)
+			}
+			if r := aws.StringValue(in.Range); r != "" {
+				var start int
+				if _, err := fmt.Sscanf(r, "bytes=%d-", &start); err != nil || start >= len(content) {
+					return nil, awserr.New("InvalidRange", r, nil)
+				}
+				content = content[start:]
+			}
+			return &s3.GetObjectOutput{
+				Body:          ioutil.NopCloser(strings.NewReader(content)),
+				ContentLength: aws.Int64(int64(len(content))),
+				LastModified:  &modified,
+			}, nil
+		},
+	)
+	s3Mock.EXPECT().HeadObjectWithContext(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(_ context.Context, in *s3.HeadObjectInput, _ ...request.Option) (*s3.HeadObjectOutput, error) {
+			content, ok := objects[*in.Key]
+			if !ok {
+				return nil, awserr.New("NotFound", "not found", nil)
+			}
+			return &s3.HeadObjectOutput{ContentLength: aws.Int64(int64(len(content))), LastModified: &modified}, nil
+		},
+	)
+	s3Mock.EXPECT().ListObjectsV2PagesWithContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(_ context.Context, in *s3.ListObjectsV2Input, f func(*s3.ListObjectsV2Output, bool) bool, _ ...request.Option) error {
+			prefix, delim := aws.StringValue(in.Prefix), aws.StringValue(in.Delimiter)
+			keys := make([]string, 0, len(objects))
+			for key := range objects {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			page := &s3.ListObjectsV2Output{}
+			seen := map[string]bool{}
+			for _, key := range keys {
+				if !strings.HasPrefix(key, prefix) {
+					continue
+				}
+				rest := key[len(prefix):]
+				if i := strings.Index(rest, delim); delim != "" && i >= 0 {
+					p := prefix + rest[:i+1]
+					if !seen[p] {
+						seen[p] = true
+						page.CommonPrefixes = append(page.CommonPrefixes, &s3.CommonPrefix{Prefix: aws.String(p)})
+					}
+					continue
+				}
+				page.Contents = append(page.Contents, &s3.Object{
+					Key:          aws.String(key),
+					Size:         aws.Int64(int64(len(objects[key]))),
+					LastModified: &modified,
+				})
+			}
+			f(page, true)
+			return nil
+		},
+	)
+}
+
+func TestBucketFS(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	expectBucket(s3Mock, map[string]string{
+		"site/index.html":       "<h1>hi</h1>",
+		"site/css/":             "",
+		"site/css/main.css":     "body{}",
+		"site/img/logo/big.png": "png",
+		"other/secret.txt":      "nope",
+	})
+
+	fsys := newWithSvc(testBucket, s3Mock).FS("site")
+	if err := fstest.TestFS(fsys, "index.html", "css/main.css", "img/logo/big.png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Open("secret.txt"); err == nil {
+		t.Error("opened an object outside the prefix")
+	}
+}
+
+func TestBucketFS_httpFS(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	expectBucket(s3Mock, map[string]string{
+		"site/page.html": "<h1>hello world</h1>",
+		"site/LICENSE":   "Permission is hereby granted",
+	})
+	handler := http.FileServer(http.FS(newWithSvc(testBucket, s3Mock).FS("site")))
+
+	tests := map[string]struct {
+		path, rng   string
+		status      int
+		body, ctype string
+	}{
+		"range":          {"/page.html", "bytes=4-8", http.StatusPartialContent, "hello", "text/html; charset=utf-8"},
+		"open range":     {"/page.html", "bytes=15-", http.StatusPartialContent, "</h1>", "text/html; charset=utf-8"},
+		"sniffed":        {"/LICENSE", "", http.StatusOK, "Permission is hereby granted", "text/plain; charset=utf-8"},
+		"sniffed range":  {"/LICENSE", "bytes=0-9", http.StatusPartialContent, "Permission", "text/plain; charset=utf-8"},
+		"missing":        {"/gone.html", "", http.StatusNotFound, "", ""},
+		"directory list": {"/", "", http.StatusOK, "", "text/html; charset=utf-8"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.rng != "" {
+				req.Header.Set("Range", tt.rng)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf(`status: want: %d, got: %d: %s`, tt.status, rec.Code, rec.Body)
+			}
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Errorf(`body: want: "%s", got: "%s"`, tt.body, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Type"); tt.ctype != "" && got != tt.ctype {
+				t.Errorf(`content type: want: "%s", got: "%s"`, tt.ctype, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package s3
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// ObjectSummary describes an object, or a common prefix, in a bucket listing.
+type ObjectSummary struct {
+	// Key of the object, or the common prefix when IsPrefix is true.
+	Key string
+
+	// Size of the object in bytes.
+	Size int64
+
+	// ETag of the object without the surrounding quotes.
+	ETag string
+
+	// LastModified is when the object was last written.
+	LastModified time.Time
+
+	// StorageClass of the object, e.g. STANDARD or GLACIER_IR.
+	StorageClass string
+
+	// IsPrefix is true when the summary is a common prefix rolled up by the
+	// listing delimiter, i.e. a "directory". Only Key is set on prefixes.
+	IsPrefix bool
+}
+
+// ListInput selects the objects to list.
+type ListInput struct {
+	// Prefix limits the listing to keys beginning with it.
+	Prefix string
+
+	// Delimiter rolls up keys sharing a prefix up to the delimiter into a
+	// single common prefix summary, e.g. "/" for directory-style listings.
+	Delimiter string
+
+	// StartAfter starts the listing after this key.
+	StartAfter string
+
+	// PageSize is the number of keys requested per page; zero uses the S3 default.
+	PageSize int64
+}
+
+// List calls fn with every object in the bucket matching in.
+// See ListWithContext.
+func (svc *Service) List(in *ListInput, fn func(ObjectSummary) bool) error {
+	return svc.ListWithContext(context.TODO(), in, fn)
+}
+
+// ListWithContext calls fn with every object in the bucket matching in.
+// Pages are requested lazily as fn consumes them, so only one page is held in
+// memory at a time. When in has a Delimiter, common prefixes are passed to fn
+// as summaries with IsPrefix set, in key order with the objects.
+// fn should return false if it wants to stop listing.
+func (svc *Service) ListWithContext(ctx context.Context, in *ListInput, fn func(ObjectSummary) bool) error {
+	if in == nil {
+		in = &ListInput{}
+	}
+	params := &s3.ListObjectsV2Input{
+		Bucket: aws.String(svc.name),
+	}
+	if in.Prefix != "" {
+		params.Prefix = aws.String(in.Prefix)
+	}
+	if in.Delimiter != "" {
+		params.Delimiter = aws.String(in.Delimiter)
+	}
+	if in.StartAfter != "" {
+		params.StartAfter = aws.String(in.StartAfter)
+	}
+	if in.PageSize > 0 {
+		params.MaxKeys = aws.Int64(in.PageSize)
+	}
+	pager := func(page *s3.ListObjectsV2Output, last bool) bool {
+		for _, obj := range pageSummaries(page) {
+			if !fn(obj) {
+				return false
+			}
+		}
+		return !last
+	}
+	return svc.svc.ListObjectsV2PagesWithContext(ctx, params, pager)
+}
+
+// ListDir calls fn with the objects and common prefixes directly under prefix.
+// See ListDirWithContext.
+func (svc *Service) ListDir(prefix string, fn func(ObjectSummary) bool) error {
+	return svc.ListDirWithContext(context.TODO(), prefix, fn)
+}
+
+// ListDirWithContext calls fn with the objects and common prefixes directly
+// under prefix, using "/" as the delimiter.
+// fn should return false if it wants to stop listing.
+func (svc *Service) ListDirWithContext(ctx context.Context, prefix string, fn func(ObjectSummary) bool) error {
+	return svc.ListWithContext(ctx, &ListInput{Prefix: prefix, Delimiter: "/"}, fn)
+}
+
+// pageSummaries merges the objects and common prefixes of a page in key order.
+func pageSummaries(page *s3.ListObjectsV2Output) []ObjectSummary {
+	out := make([]ObjectSummary, 0, len(page.Contents)+len(page.CommonPrefixes))
+	objs, prefixes := page.Contents, page.CommonPrefixes
+	for len(objs) > 0 || len(prefixes) > 0 {
+		if len(prefixes) == 0 || (len(objs) > 0 && aws.StringValue(objs[0].Key) < aws.StringValue(prefixes[0].Prefix)) {
+			if objs[0].Key != nil {
+				out = append(out, objectSummary(objs[0]))
+			}
+			objs = objs[1:]
+			continue
+		}
+		if prefixes[0].Prefix != nil {
+			out = append(out, ObjectSummary{Key: *prefixes[0].Prefix, IsPrefix: true})
+		}
+		prefixes = prefixes[1:]
+	}
+	return out
+}
+
+func objectSummary(obj *s3.Object) ObjectSummary {
+	return ObjectSummary{
+		Key:          aws.StringValue(obj.Key),
+		Size:         aws.Int64Value(obj.Size),
+		ETag:         trimETag(obj.ETag),
+		LastModified: aws.TimeValue(obj.LastModified),
+		StorageClass: aws.StringValue(obj.StorageClass),
+	}
+}
+
+func trimETag(etag *string) string {
+	return strings.Trim(aws.StringValue(etag), `"`)
+}
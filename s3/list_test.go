@@ -0,0 +1,118 @@
+package s3
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cleardataeng/aidews/s3/extmocks/github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+)
+
+var (
+	ctx = context.Background()
+
+	modified = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	listPage1 = &s3.ListObjectsV2Output{
+		Contents: []*s3.Object{
+			{Key: aws.String("keys/a.txt"), Size: aws.Int64(1), ETag: aws.String(`"aa"`), LastModified: &modified, StorageClass: aws.String("STANDARD")},
+			{Key: aws.String("keys/c.txt"), Size: aws.Int64(3), ETag: aws.String(`"cc"`), LastModified: &modified, StorageClass: aws.String("GLACIER_IR")},
+		},
+		CommonPrefixes: []*s3.CommonPrefix{{Prefix: aws.String("keys/b/")}},
+	}
+	listPage2 = &s3.ListObjectsV2Output{
+		CommonPrefixes: []*s3.CommonPrefix{{Prefix: aws.String("keys/d/")}},
+	}
+)
+
+func expectListPages(t *testing.T, s3Mock *mock_s3iface.MockS3API, wantDelimiter string, stopsAt int) {
+	s3Mock.EXPECT().ListObjectsV2PagesWithContext(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.ListObjectsV2Input, f func(*s3.ListObjectsV2Output, bool) bool, _ ...request.Option) error {
+			if got := aws.StringValue(in.Bucket); got != testBucket {
+				t.Errorf(`bucket: want: "%s", got: "%s"`, testBucket, got)
+			}
+			if got := aws.StringValue(in.Delimiter); got != wantDelimiter {
+				t.Errorf(`delimiter: want: "%s", got: "%s"`, wantDelimiter, got)
+			}
+			if f(listPage1, false) != (stopsAt > 1) {
+				t.Errorf("page 1: pager did not report whether to continue")
+			}
+			if stopsAt > 1 && f(listPage2, true) {
+				t.Error("page 2: pager wanted to continue past the last page")
+			}
+			return nil
+		},
+	)
+}
+
+func TestService_ListDirWithContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	expectListPages(t, s3Mock, "/", 2)
+
+	var got []ObjectSummary
+	svc := newWithSvc(testBucket, s3Mock)
+	err := svc.ListDirWithContext(ctx, "keys/", func(obj ObjectSummary) bool {
+		got = append(got, obj)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ObjectSummary{
+		{Key: "keys/a.txt", Size: 1, ETag: "aa", LastModified: modified, StorageClass: "STANDARD"},
+		{Key: "keys/b/", IsPrefix: true},
+		{Key: "keys/c.txt", Size: 3, ETag: "cc", LastModified: modified, StorageClass: "GLACIER_IR"},
+		{Key: "keys/d/", IsPrefix: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("summaries: wanted: %+v, got: %+v", want, got)
+	}
+}
+
+func TestService_ListWithContextStop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	expectListPages(t, s3Mock, "", 1)
+
+	var keys []string
+	svc := newWithSvc(testBucket, s3Mock)
+	err := svc.ListWithContext(ctx, &ListInput{Prefix: "keys/"}, func(obj ObjectSummary) bool {
+		keys = append(keys, obj.Key)
+		return len(keys) < 2
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"keys/a.txt", "keys/b/"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys: wanted: %v, got: %v", want, keys)
+	}
+}
+
+func TestService_ListObjectsKeysV2PagesEmptyLastPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	s3Mock.EXPECT().ListObjectsV2Pages(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ *s3.ListObjectsV2Input, f func(*s3.ListObjectsV2Output, bool) bool) error {
+			f(&s3.ListObjectsV2Output{}, true)
+			return nil
+		},
+	)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	keys, last, err := svc.ListObjectsKeysV2Pages(svc.ListObjectsV2Input())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 || !last {
+		t.Errorf("want no keys on the last page, got: %v, last: %t", keys, last)
+	}
+}
@@ -0,0 +1,234 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Object lock retention modes.
+const (
+	// RetentionGovernance prevents deletes and retention changes, except by
+	// users with s3:BypassGovernanceRetention who ask to bypass it.
+	RetentionGovernance = s3.ObjectLockRetentionModeGovernance
+
+	// RetentionCompliance prevents deletes and shortening the retention by
+	// any user, including the root user, until the retain-until date.
+	RetentionCompliance = s3.ObjectLockRetentionModeCompliance
+)
+
+// Retention is the object lock retention of an object version.
+type Retention struct {
+	Mode        string
+	RetainUntil time.Time
+}
+
+// ErrObjectLocked is matched, with errors.Is, by the errors of operations
+// blocked by object lock retention or a legal hold.
+var ErrObjectLocked = errors.New("object protected by object lock")
+
+// ObjectLockedError is the failure of an operation on an object version
+// that object lock retention or a legal hold blocked.
+//
+// Mode is the retention mode of the version, read with GetObjectRetention
+// after the denial. It is empty when the version has no retention, so a legal
+// hold blocked the operation, or when the retention could not be read.
+type ObjectLockedError struct {
+	Key       string
+	VersionID string
+	Mode      string
+	Err       error
+}
+
+func (e *ObjectLockedError) Error() string {
+	if e.VersionID == "" {
+		return fmt.Sprintf("%s: %v", e.Key, e.Err)
+	}
+	return fmt.Sprintf("%s (version %s): %v", e.Key, e.VersionID, e.Err)
+}
+
+// Is reports whether target is ErrObjectLocked.
+func (e *ObjectLockedError) Is(target error) bool {
+	return target == ErrObjectLocked
+}
+
+func (e *ObjectLockedError) Unwrap() error {
+	return e.Err
+}
+
+// isObjectLockDenial reports whether the error code and message are S3
+// denying an operation because of object lock.
+func isObjectLockDenial(code, message string) bool {
+	return code == "AccessDenied" && strings.Contains(strings.ToLower(message), "object lock")
+}
+
+// lockError returns err as an ObjectLockedError if object lock blocked the
+// operation on the version of the key in the bucket, and otherwise unchanged.
+func (svc *Service) lockError(ctx context.Context, err error, bucket, key, versionID string) error {
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) || !isObjectLockDenial(aerr.Code(), aerr.Message()) {
+		return err
+	}
+	lerr := &ObjectLockedError{Key: key, VersionID: versionID, Err: err}
+	if r, rerr := svc.retention(ctx, bucket, key, versionID); rerr == nil && r != nil {
+		lerr.Mode = r.Mode
+	}
+	return lerr
+}
+
+// DeleteVersion permanently deletes the version of the object at the key.
+// See DeleteVersionWithContext.
+func (svc *Service) DeleteVersion(key, versionID string, bypassGovernance bool) error {
+	return svc.DeleteVersionWithContext(context.TODO(), key, versionID, bypassGovernance)
+}
+
+// DeleteVersionWithContext permanently deletes the version of the object at
+// the key. Versions under governance retention are deleted only if
+// bypassGovernance is set and the caller is allowed to bypass it. Deletes
+// blocked by object lock fail with an ObjectLockedError. versionID must not be
+// empty; use Delete for the current version.
+func (svc *Service) DeleteVersionWithContext(ctx context.Context, key, versionID string, bypassGovernance bool) error {
+	if versionID == "" {
+		return fmt.Errorf("delete version of %s: empty version ID", key)
+	}
+	in := &s3.DeleteObjectInput{
+		Bucket:    aws.String(svc.name),
+		Key:       aws.String(key),
+		VersionId: aws.String(versionID),
+	}
+	if bypassGovernance {
+		in.BypassGovernanceRetention = aws.Bool(true)
+	}
+	_, err := svc.svc.DeleteObjectWithContext(ctx, in)
+	return svc.lockError(ctx, err, svc.name, key, versionID)
+}
+
+// GetRetention gets the retention of the version of the object at the key, or
+// of the current version when versionID is empty.
+// See GetRetentionWithContext.
+func (svc *Service) GetRetention(key, versionID string) (*Retention, error) {
+	return svc.GetRetentionWithContext(context.TODO(), key, versionID)
+}
+
+// GetRetentionWithContext gets the retention of the version of the object at
+// the key, or of the current version when versionID is empty. It returns nil
+// if the version has no retention.
+func (svc *Service) GetRetentionWithContext(ctx context.Context, key, versionID string) (*Retention, error) {
+	return svc.retention(ctx, svc.name, key, versionID)
+}
+
+// retention gets the retention of the version of the object at the key in
+// the bucket.
+func (svc *Service) retention(ctx context.Context, bucket, key, versionID string) (*Retention, error) {
+	in := &s3.GetObjectRetentionInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if versionID != "" {
+		in.VersionId = aws.String(versionID)
+	}
+	res, err := svc.svc.GetObjectRetentionWithContext(ctx, in)
+	if hasErrorCode(err, "NoSuchObjectLockConfiguration") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if res.Retention == nil || res.Retention.Mode == nil {
+		return nil, nil
+	}
+	return &Retention{
+		Mode:        aws.StringValue(res.Retention.Mode),
+		RetainUntil: aws.TimeValue(res.Retention.RetainUntilDate),
+	}, nil
+}
+
+// SetRetention sets the retention of the version of the object at the key, or
+// of the current version when versionID is empty.
+// See SetRetentionWithContext.
+func (svc *Service) SetRetention(key, versionID string, r *Retention, bypassGovernance bool) error {
+	return svc.SetRetentionWithContext(context.TODO(), key, versionID, r, bypassGovernance)
+}
+
+// SetRetentionWithContext sets the retention of the version of the object at
+// the key, or of the current version when versionID is empty. Governance
+// retention is shortened or removed, with a nil r, only if bypassGovernance is
+// set. Changes blocked by object lock fail with an ObjectLockedError.
+func (svc *Service) SetRetentionWithContext(ctx context.Context, key, versionID string, r *Retention, bypassGovernance bool) error {
+	in := &s3.PutObjectRetentionInput{
+		Bucket:    aws.String(svc.name),
+		Key:       aws.String(key),
+		Retention: &s3.ObjectLockRetention{},
+	}
+	if versionID != "" {
+		in.VersionId = aws.String(versionID)
+	}
+	if r != nil {
+		in.Retention.Mode = aws.String(r.Mode)
+		in.Retention.RetainUntilDate = aws.Time(r.RetainUntil)
+	}
+	if bypassGovernance {
+		in.BypassGovernanceRetention = aws.Bool(true)
+	}
+	_, err := svc.svc.PutObjectRetentionWithContext(ctx, in)
+	return svc.lockError(ctx, err, svc.name, key, versionID)
+}
+
+// GetLegalHold reports whether the version of the object at the key, or the
+// current version when versionID is empty, is under a legal hold.
+// See GetLegalHoldWithContext.
+func (svc *Service) GetLegalHold(key, versionID string) (bool, error) {
+	return svc.GetLegalHoldWithContext(context.TODO(), key, versionID)
+}
+
+// GetLegalHoldWithContext reports whether the version of the object at the
+// key, or the current version when versionID is empty, is under a legal hold.
+func (svc *Service) GetLegalHoldWithContext(ctx context.Context, key, versionID string) (bool, error) {
+	in := &s3.GetObjectLegalHoldInput{
+		Bucket: aws.String(svc.name),
+		Key:    aws.String(key),
+	}
+	if versionID != "" {
+		in.VersionId = aws.String(versionID)
+	}
+	res, err := svc.svc.GetObjectLegalHoldWithContext(ctx, in)
+	if hasErrorCode(err, "NoSuchObjectLockConfiguration") {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return res.LegalHold != nil && aws.StringValue(res.LegalHold.Status) == s3.ObjectLockLegalHoldStatusOn, nil
+}
+
+// SetLegalHold places or removes a legal hold on the version of the object at
+// the key, or on the current version when versionID is empty.
+// See SetLegalHoldWithContext.
+func (svc *Service) SetLegalHold(key, versionID string, on bool) error {
+	return svc.SetLegalHoldWithContext(context.TODO(), key, versionID, on)
+}
+
+// SetLegalHoldWithContext places or removes a legal hold on the version of the
+// object at the key, or on the current version when versionID is empty.
+func (svc *Service) SetLegalHoldWithContext(ctx context.Context, key, versionID string, on bool) error {
+	status := s3.ObjectLockLegalHoldStatusOff
+	if on {
+		status = s3.ObjectLockLegalHoldStatusOn
+	}
+	in := &s3.PutObjectLegalHoldInput{
+		Bucket:    aws.String(svc.name),
+		Key:       aws.String(key),
+		LegalHold: &s3.ObjectLockLegalHold{Status: aws.String(status)},
+	}
+	if versionID != "" {
+		in.VersionId = aws.String(versionID)
+	}
+	_, err := svc.svc.PutObjectLegalHoldWithContext(ctx, in)
+	return svc.lockError(ctx, err, svc.name, key, versionID)
+}
@@ -0,0 +1,213 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cleardataeng/aidews/s3/extmocks/github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+)
+
+var retainUntil = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestService_PutWithOptionsLock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	s3Mock.EXPECT().PutObjectWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.PutObjectInput, _ ...request.Option) (*s3.PutObjectOutput, error) {
+			if got := aws.StringValue(in.ObjectLockMode); got != RetentionCompliance {
+				t.Errorf(`mode: want: "%s", got: "%s"`, RetentionCompliance, got)
+			}
+			if got := aws.TimeValue(in.ObjectLockRetainUntilDate); !got.Equal(retainUntil) {
+				t.Errorf(`retain until: want: "%v", got: "%v"`, retainUntil, got)
+			}
+			if got := aws.StringValue(in.ObjectLockLegalHoldStatus); got != s3.ObjectLockLegalHoldStatusOn {
+				t.Errorf(`legal hold: want: "%s", got: "%s"`, s3.ObjectLockLegalHoldStatusOn, got)
+			}
+			return &s3.PutObjectOutput{}, nil
+		},
+	)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	_, err := svc.PutWithOptionsWithContext(ctx, "records/1", bytes.NewReader([]byte("{}")), &PutOptions{
+		Retention: &Retention{Mode: RetentionCompliance, RetainUntil: retainUntil},
+		LegalHold: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_DeleteVersionLocked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	s3Mock.EXPECT().DeleteObjectWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.DeleteObjectInput, _ ...request.Option) (*s3.DeleteObjectOutput, error) {
+			if !aws.BoolValue(in.BypassGovernanceRetention) {
+				t.Error("expected governance bypass")
+			}
+			return nil, awserr.New("AccessDenied", "Access Denied because object protected by object lock.", nil)
+		},
+	)
+	s3Mock.EXPECT().GetObjectRetentionWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.GetObjectRetentionInput, _ ...request.Option) (*s3.GetObjectRetentionOutput, error) {
+			if aws.StringValue(in.VersionId) != "v1" {
+				t.Errorf(`version id: want: "v1", got: "%s"`, aws.StringValue(in.VersionId))
+			}
+			return &s3.GetObjectRetentionOutput{
+				Retention: &s3.ObjectLockRetention{
+					Mode:            aws.String(RetentionCompliance),
+					RetainUntilDate: aws.Time(retainUntil),
+				},
+			}, nil
+		},
+	)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	err := svc.DeleteVersionWithContext(ctx, "records/1", "v1", true)
+	if !errors.Is(err, ErrObjectLocked) {
+		t.Fatalf(`err: want: ErrObjectLocked, got: %v`, err)
+	}
+	var lerr *ObjectLockedError
+	if !errors.As(err, &lerr) || lerr.Key != "records/1" || lerr.VersionID != "v1" {
+		t.Fatalf(`err: want: ObjectLockedError for records/1 v1, got: %#v`, err)
+	}
+	if lerr.Mode != RetentionCompliance {
+		t.Errorf(`mode: want: "%s", got: "%s"`, RetentionCompliance, lerr.Mode)
+	}
+}
+
+func TestService_DeleteVersionEmpty(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	if err := svc.DeleteVersionWithContext(ctx, "records/1", "", false); err == nil {
+		t.Error("expected an error for an empty version ID")
+	}
+}
+
+func TestService_writesLocked(t *testing.T) {
+	denied := awserr.New("AccessDenied", "Access Denied because object protected by object lock.", nil)
+	tests := map[string]struct {
+		expect func(*mock_s3iface.MockS3API)
+		call   func(*Service) error
+	}{
+		"put": {
+			func(m *mock_s3iface.MockS3API) { m.EXPECT().PutObject(gomock.Any()).Return(nil, denied) },
+			func(svc *Service) error {
+				_, err := svc.Put("records/1", bytes.NewReader([]byte("{}")))
+				return err
+			},
+		},
+		"put marshal": {
+			func(m *mock_s3iface.MockS3API) { m.EXPECT().PutObject(gomock.Any()).Return(nil, denied) },
+			func(svc *Service) error {
+				_, err := svc.PutMarshal("records/1", map[string]int{"a": 1})
+				return err
+			},
+		},
+		"delete": {
+			func(m *mock_s3iface.MockS3API) {
+				m.EXPECT().DeleteObjectWithContext(gomock.Any(), gomock.Any()).Return(nil, denied)
+			},
+			func(svc *Service) error {
+				_, err := svc.Delete("records/1")
+				return err
+			},
+		},
+		"copy": {
+			func(m *mock_s3iface.MockS3API) {
+				m.EXPECT().HeadObjectWithContext(gomock.Any(), gomock.Any()).Return(&s3.HeadObjectOutput{ContentLength: aws.Int64(2)}, nil)
+				m.EXPECT().CopyObjectWithContext(gomock.Any(), gomock.Any()).Return(nil, denied)
+			},
+			func(svc *Service) error {
+				_, err := svc.Copy("drafts/1", testBucket, "records/1")
+				return err
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			s3Mock := mock_s3iface.NewMockS3API(ctrl)
+			tt.expect(s3Mock)
+			// a legal hold without retention
+			s3Mock.EXPECT().GetObjectRetentionWithContext(gomock.Any(), gomock.Any()).Return(
+				nil, awserr.New("NoSuchObjectLockConfiguration", "", nil),
+			)
+
+			err := tt.call(newWithSvc(testBucket, s3Mock))
+			var lerr *ObjectLockedError
+			if !errors.As(err, &lerr) || lerr.Key != "records/1" {
+				t.Fatalf(`err: want: ObjectLockedError for records/1, got: %#v`, err)
+			}
+			if lerr.Mode != "" {
+				t.Errorf(`mode: want: "", got: "%s"`, lerr.Mode)
+			}
+		})
+	}
+}
+
+func TestService_DeleteManyLocked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	s3Mock.EXPECT().DeleteObjectsWithContext(ctx, gomock.Any()).Return(&s3.DeleteObjectsOutput{
+		Errors: []*s3.Error{{
+			Key:     aws.String("records/1"),
+			Code:    aws.String("AccessDenied"),
+			Message: aws.String("Access Denied because object protected by object lock."),
+		}},
+	}, nil)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	err := svc.DeleteManyWithContext(ctx, []string{"records/1", "records/2"})
+	var failed BatchError
+	if !errors.As(err, &failed) || len(failed) != 1 {
+		t.Fatalf(`err: want: BatchError of 1, got: %v`, err)
+	}
+	if !errors.Is(failed[0], ErrObjectLocked) {
+		t.Errorf(`err: want: ErrObjectLocked, got: %v`, failed[0])
+	}
+}
+
+func TestService_Retention(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	s3Mock.EXPECT().GetObjectRetentionWithContext(ctx, gomock.Any()).Return(&s3.GetObjectRetentionOutput{
+		Retention: &s3.ObjectLockRetention{
+			Mode:            aws.String(RetentionGovernance),
+			RetainUntilDate: aws.Time(retainUntil),
+		},
+	}, nil)
+	s3Mock.EXPECT().GetObjectLegalHoldWithContext(ctx, gomock.Any()).Return(nil, awserr.New("NoSuchObjectLockConfiguration", "", nil))
+
+	svc := newWithSvc(testBucket, s3Mock)
+	r, err := svc.GetRetentionWithContext(ctx, "records/1", "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Retention{Mode: RetentionGovernance, RetainUntil: retainUntil}); r == nil || *r != want {
+		t.Errorf(`retention: want: %+v, got: %+v`, want, r)
+	}
+	hold, err := svc.GetLegalHoldWithContext(ctx, "records/1", "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hold {
+		t.Error("legal hold: want: false, got: true")
+	}
+}
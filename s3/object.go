@@ -0,0 +1,231 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// PutOptions are the settings of a single put. Empty fields are not set,
+// leaving the Service defaults or S3 defaults in place.
+type PutOptions struct {
+	CacheControl       string
+	ContentDisposition string
+	ContentEncoding    string
+	ContentType        string
+
+	// Metadata is stored as user metadata (x-amz-meta-*) on the object.
+	Metadata map[string]string
+
+	// StorageClass overrides the Service storage class, e.g. GLACIER_IR or
+	// INTELLIGENT_TIERING.
+	StorageClass string
+
+	// Tags are added to the Service default tags, replacing any with the same key.
+	Tags map[string]string
+
+	// Retention and LegalHold lock the object version in a bucket with object
+	// lock enabled. S3 requires a Content-MD5 for these, which is computed
+	// only when the content is an io.Seeker.
+	Retention *Retention
+	LegalHold bool
+}
+
+// ObjectInfo is the metadata of an object.
+type ObjectInfo struct {
+	Key                string
+	VersionID          string
+	Size               int64
+	ETag               string
+	LastModified       time.Time
+	CacheControl       string
+	ContentDisposition string
+	ContentEncoding    string
+	ContentType        string
+
+	// Metadata is the user metadata of the object, with lower case keys.
+	Metadata map[string]string
+
+	// StorageClass of the object; STANDARD when S3 does not report one.
+	StorageClass string
+
+	// ServerSideEncryption is the algorithm, and KMSKeyID the KMS key, with
+	// which S3 encrypted the object.
+	ServerSideEncryption string
+	KMSKeyID             string
+}
+
+// PutWithOptions puts the content to the bucket at the key with opts.
+// See PutWithOptionsWithContext.
+func (svc *Service) PutWithOptions(key string, content io.Reader, opts *PutOptions) (*s3.PutObjectOutput, error) {
+	return svc.PutWithOptionsWithContext(context.TODO(), key, content, opts)
+}
+
+// PutWithOptionsWithContext puts the content to the bucket at the key with
+// opts, which may be nil.
+func (svc *Service) PutWithOptionsWithContext(ctx context.Context, key string, content io.Reader, opts *PutOptions) (*s3.PutObjectOutput, error) {
+	in := svc.putInput(key, content)
+	if opts != nil {
+		if opts.CacheControl != "" {
+			in.CacheControl = aws.String(opts.CacheControl)
+		}
+		if opts.ContentDisposition != "" {
+			in.ContentDisposition = aws.String(opts.ContentDisposition)
+		}
+		if opts.ContentEncoding != "" {
+			in.ContentEncoding = aws.String(opts.ContentEncoding)
+		}
+		if opts.ContentType != "" {
+			in.ContentType = aws.String(opts.ContentType)
+		}
+		if len(opts.Metadata) > 0 {
+			in.Metadata = aws.StringMap(opts.Metadata)
+		}
+		if opts.StorageClass != "" {
+			in.StorageClass = aws.String(opts.StorageClass)
+		}
+		in.Tagging = svc.tagging(opts.Tags)
+		if opts.Retention != nil {
+			in.ObjectLockMode = aws.String(opts.Retention.Mode)
+			in.ObjectLockRetainUntilDate = aws.Time(opts.Retention.RetainUntil)
+		}
+		if opts.LegalHold {
+			in.ObjectLockLegalHoldStatus = aws.String(s3.ObjectLockLegalHoldStatusOn)
+		}
+	}
+	res, err := svc.svc.PutObjectWithContext(ctx, in)
+	return res, svc.lockError(ctx, err, svc.name, key, "")
+}
+
+// Head gets the metadata of the object at the key without reading its content.
+// See HeadWithContext.
+func (svc *Service) Head(key string) (*ObjectInfo, error) {
+	return svc.HeadWithContext(context.TODO(), key)
+}
+
+// HeadWithContext gets the metadata of the object at the key without reading its content.
+func (svc *Service) HeadWithContext(ctx context.Context, key string) (*ObjectInfo, error) {
+	in := &s3.HeadObjectInput{
+		Bucket: aws.String(svc.name),
+		Key:    aws.String(key),
+	}
+	svc.sse.applyHead(in)
+	res, err := svc.svc.HeadObjectWithContext(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	info := &ObjectInfo{
+		Key:                  key,
+		VersionID:            aws.StringValue(res.VersionId),
+		Size:                 aws.Int64Value(res.ContentLength),
+		ETag:                 trimETag(res.ETag),
+		LastModified:         aws.TimeValue(res.LastModified),
+		CacheControl:         aws.StringValue(res.CacheControl),
+		ContentDisposition:   aws.StringValue(res.ContentDisposition),
+		ContentEncoding:      aws.StringValue(res.ContentEncoding),
+		ContentType:          aws.StringValue(res.ContentType),
+		Metadata:             make(map[string]string, len(res.Metadata)),
+		StorageClass:         aws.StringValue(res.StorageClass),
+		ServerSideEncryption: aws.StringValue(res.ServerSideEncryption),
+		KMSKeyID:             aws.StringValue(res.SSEKMSKeyId),
+	}
+	for k, v := range res.Metadata {
+		info.Metadata[strings.ToLower(k)] = aws.StringValue(v)
+	}
+	if info.StorageClass == "" {
+		info.StorageClass = s3.StorageClassStandard
+	}
+	return info, nil
+}
+
+// GetTags gets the tags of the object at the key.
+// See GetTagsWithContext.
+func (svc *Service) GetTags(key string) (map[string]string, error) {
+	return svc.GetTagsWithContext(context.TODO(), key)
+}
+
+// GetTagsWithContext gets the tags of the object at the key.
+func (svc *Service) GetTagsWithContext(ctx context.Context, key string) (map[string]string, error) {
+	res, err := svc.svc.GetObjectTaggingWithContext(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(svc.name),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	tags := tagMap(res.TagSet)
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	return tags, nil
+}
+
+// SetTags replaces the tags of the object at the key.
+// See SetTagsWithContext.
+func (svc *Service) SetTags(key string, tags map[string]string) error {
+	return svc.SetTagsWithContext(context.TODO(), key, tags)
+}
+
+// SetTagsWithContext replaces the tags of the object at the key.
+func (svc *Service) SetTagsWithContext(ctx context.Context, key string, tags map[string]string) error {
+	_, err := svc.svc.PutObjectTaggingWithContext(ctx, &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(svc.name),
+		Key:     aws.String(key),
+		Tagging: &s3.Tagging{TagSet: tagSet(tags)},
+	})
+	return err
+}
+
+// tagSet returns tags as S3 tags sorted by key.
+func tagSet(tags map[string]string) []*s3.Tag {
+	set := make([]*s3.Tag, 0, len(tags))
+	for k, v := range tags {
+		set = append(set, &s3.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	sort.Slice(set, func(i, j int) bool { return *set[i].Key < *set[j].Key })
+	return set
+}
+
+// tagMap returns S3 tags as a map, or nil if there are none.
+func tagMap(set []*s3.Tag) map[string]string {
+	if len(set) == 0 {
+		return nil
+	}
+	tags := make(map[string]string, len(set))
+	for _, tag := range set {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	return tags
+}
+
+// SetDefaultTags sets the tags with which objects will be stored.
+func (svc *Service) SetDefaultTags(tags map[string]string) {
+	svc.tags = tags
+}
+
+// SetStorageClass sets the storage class with which objects will be stored.
+// A nil string leaves the choice to S3, which uses STANDARD.
+func (svc *Service) SetStorageClass(v *string) {
+	svc.storageClass = v
+}
+
+// tagging returns the URL encoded tag header for the default tags and extra.
+func (svc *Service) tagging(extra map[string]string) *string {
+	if len(svc.tags) == 0 && len(extra) == 0 {
+		return nil
+	}
+	v := url.Values{}
+	for k, val := range svc.tags {
+		v.Set(k, val)
+	}
+	for k, val := range extra {
+		v.Set(k, val)
+	}
+	return aws.String(v.Encode())
+}
@@ -0,0 +1,176 @@
+package s3
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cleardataeng/aidews/s3/extmocks/github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+)
+
+func TestService_PutWithOptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	s3Mock.EXPECT().PutObjectWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.PutObjectInput, _ ...request.Option) (*s3.PutObjectOutput, error) {
+			if got := aws.StringValue(in.Tagging); got != "env=prod&team=web" {
+				t.Errorf(`tagging: want: "env=prod&team=web", got: "%s"`, got)
+			}
+			if got := aws.StringValue(in.StorageClass); got != s3.StorageClassGlacierIr {
+				t.Errorf(`storage class: want: "%s", got: "%s"`, s3.StorageClassGlacierIr, got)
+			}
+			if got := aws.StringValue(in.CacheControl); got != "max-age=60" {
+				t.Errorf(`cache control: want: "max-age=60", got: "%s"`, got)
+			}
+			if got := aws.StringValueMap(in.Metadata); !reflect.DeepEqual(got, map[string]string{"source": "batch"}) {
+				t.Errorf(`metadata: want: map[source:batch], got: %v`, got)
+			}
+			return &s3.PutObjectOutput{}, nil
+		},
+	)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	svc.SetDefaultTags(map[string]string{"env": "dev", "team": "web"})
+	svc.SetStorageClass(aws.String(s3.StorageClassIntelligentTiering))
+	_, err := svc.PutWithOptionsWithContext(ctx, "doc", strings.NewReader("{}"), &PutOptions{
+		CacheControl: "max-age=60",
+		Metadata:     map[string]string{"source": "batch"},
+		StorageClass: s3.StorageClassGlacierIr,
+		Tags:         map[string]string{"env": "prod"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_defaultTagsAndStorageClass(t *testing.T) {
+	check := func(t *testing.T, tagging, storageClass *string) {
+		if got := aws.StringValue(tagging); got != "env=dev&team=web" {
+			t.Errorf(`tagging: want: "env=dev&team=web", got: "%s"`, got)
+		}
+		if got := aws.StringValue(storageClass); got != s3.StorageClassIntelligentTiering {
+			t.Errorf(`storage class: want: "%s", got: "%s"`, s3.StorageClassIntelligentTiering, got)
+		}
+	}
+	tests := map[string]struct {
+		expect func(*testing.T, *mock_s3iface.MockS3API)
+		call   func(*Service) error
+	}{
+		"put marshal": {
+			func(t *testing.T, m *mock_s3iface.MockS3API) {
+				m.EXPECT().PutObject(gomock.Any()).DoAndReturn(
+					func(in *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+						check(t, in.Tagging, in.StorageClass)
+						return &s3.PutObjectOutput{}, nil
+					},
+				)
+			},
+			func(svc *Service) error {
+				_, err := svc.PutMarshal("doc", map[string]int{"a": 1})
+				return err
+			},
+		},
+		"upload": {
+			func(t *testing.T, m *mock_s3iface.MockS3API) {
+				m.EXPECT().PutObjectRequest(gomock.Any()).DoAndReturn(
+					func(in *s3.PutObjectInput) (*request.Request, *s3.PutObjectOutput) {
+						check(t, in.Tagging, in.StorageClass)
+						out := &s3.PutObjectOutput{}
+						return request.New(aws.Config{}, metadata.ClientInfo{}, request.Handlers{}, nil, &request.Operation{Name: "PutObject"}, in, out), out
+					},
+				)
+			},
+			func(svc *Service) error {
+				_, err := svc.Upload("doc", strings.NewReader("{}"))
+				return err
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			s3Mock := mock_s3iface.NewMockS3API(ctrl)
+			tt.expect(t, s3Mock)
+
+			svc := newWithSvc(testBucket, s3Mock)
+			svc.SetDefaultTags(map[string]string{"env": "dev", "team": "web"})
+			svc.SetStorageClass(aws.String(s3.StorageClassIntelligentTiering))
+			if err := tt.call(svc); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestService_HeadWithContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	s3Mock.EXPECT().HeadObjectWithContext(ctx, gomock.Any()).Return(&s3.HeadObjectOutput{
+		ContentLength: aws.Int64(42),
+		ContentType:   aws.String("text/csv"),
+		ETag:          aws.String(`"abc"`),
+		LastModified:  &modified,
+		Metadata:      map[string]*string{"Source": aws.String("batch")},
+	}, nil)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	got, err := svc.HeadWithContext(ctx, "doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ObjectInfo{
+		Key:          "doc",
+		Size:         42,
+		ETag:         "abc",
+		LastModified: modified,
+		ContentType:  "text/csv",
+		Metadata:     map[string]string{"source": "batch"},
+		StorageClass: s3.StorageClassStandard,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("info: wanted: %+v, got: %+v", want, got)
+	}
+}
+
+func TestService_SetTagsWithContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	var stored []*s3.Tag
+	s3Mock.EXPECT().PutObjectTaggingWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.PutObjectTaggingInput, _ ...request.Option) (*s3.PutObjectTaggingOutput, error) {
+			stored = in.Tagging.TagSet
+			return &s3.PutObjectTaggingOutput{}, nil
+		},
+	)
+	s3Mock.EXPECT().GetObjectTaggingWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.GetObjectTaggingInput, _ ...request.Option) (*s3.GetObjectTaggingOutput, error) {
+			return &s3.GetObjectTaggingOutput{TagSet: stored}, nil
+		},
+	)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	tags := map[string]string{"team": "data", "tier": "hot"}
+	if err := svc.SetTagsWithContext(ctx, "doc", tags); err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 2 || aws.StringValue(stored[0].Key) != "team" {
+		t.Errorf("tag set: want sorted by key, got: %v", stored)
+	}
+	got, err := svc.GetTagsWithContext(ctx, "doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, tags) {
+		t.Errorf("tags: wanted: %v, got: %v", tags, got)
+	}
+}
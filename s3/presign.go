@@ -0,0 +1,203 @@
+package s3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// now is the clock of PresignPost, replaced in tests.
+var now = time.Now
+
+// PresignGet returns a URL from which the object at the key can be downloaded,
+// without credentials, until ttl, which must be positive, has passed. The
+// download must be made with the returned headers, which carry the customer provided key of the Service
+// SSE settings, if any, and are otherwise empty.
+func (svc *Service) PresignGet(key string, ttl time.Duration) (string, http.Header, error) {
+	in := &s3.GetObjectInput{
+		Bucket: aws.String(svc.name),
+		Key:    aws.String(key),
+	}
+	svc.sse.applyGet(in)
+	req, _ := svc.svc.GetObjectRequest(in)
+	return presign(req, ttl)
+}
+
+// PresignPut returns a URL to which content can be put at the key, without
+// credentials, until ttl, which must be positive, has passed. The upload must
+// be made with the returned headers, which carry the Service ACL, SSE, default
+// tags and storage class settings and the content type.
+func (svc *Service) PresignPut(key string, ttl time.Duration, contentType string) (string, http.Header, error) {
+	in := &s3.PutObjectInput{
+		ACL:          svc.acl,
+		Bucket:       aws.String(svc.name),
+		Key:          aws.String(key),
+		StorageClass: svc.storageClass,
+		Tagging:      svc.tagging(nil),
+	}
+	if contentType != "" {
+		in.ContentType = aws.String(contentType)
+	}
+	svc.sse.applyPut(in)
+	req, _ := svc.svc.PutObjectRequest(in)
+	return presign(req, ttl)
+}
+
+// presign returns the presigned URL of req and the headers it must be sent
+// with.
+func presign(req *request.Request, ttl time.Duration) (string, http.Header, error) {
+	if ttl <= 0 {
+		return "", nil, fmt.Errorf("presign: ttl must be positive, got %v", ttl)
+	}
+	signed, signedHeader, err := req.PresignRequest(ttl)
+	if err != nil {
+		return "", nil, err
+	}
+	// the signer returns lower case names, which http.Header.Get would miss
+	header := http.Header{}
+	for name, values := range signedHeader {
+		for _, v := range values {
+			header.Add(name, v)
+		}
+	}
+	return signed, header, nil
+}
+
+// PostPolicy restricts browser uploads made with a presigned POST form.
+type PostPolicy struct {
+	// KeyPrefix is the prefix uploaded keys must start with.
+	KeyPrefix string
+
+	// MinSize and MaxSize bound the size of uploads in bytes. The size is not
+	// restricted when MaxSize is zero.
+	MinSize, MaxSize int64
+
+	// ContentTypePrefix is the prefix uploaded content types must start with,
+	// e.g. "image/". The content type is not restricted when empty.
+	ContentTypePrefix string
+
+	// TTL is how long the form can be used, and must be positive.
+	TTL time.Duration
+}
+
+// PresignedPost is an HTML form upload signed for a PostPolicy.
+type PresignedPost struct {
+	// URL is the form action.
+	URL string
+
+	// Fields are the form fields to include before the file field. The key
+	// field uploads under the policy KeyPrefix with the browser file name, and
+	// may be replaced with any key starting with the prefix.
+	Fields map[string]string
+}
+
+// PresignPost returns an HTML form, signed with the Service credentials, for
+// uploading to the bucket from a browser. Uploads are stored with the Service
+// ACL and server side encryption settings. Customer provided keys are not
+// included in the form.
+func (svc *Service) PresignPost(p *PostPolicy) (*PresignedPost, error) {
+	if p == nil {
+		return nil, errors.New("presign post: nil PostPolicy")
+	}
+	if p.TTL <= 0 {
+		return nil, fmt.Errorf("presign post: TTL must be positive, got %v", p.TTL)
+	}
+	// build a request to resolve the bucket endpoint, region and credentials
+	req, _ := svc.svc.ListObjectsV2Request(&s3.ListObjectsV2Input{Bucket: aws.String(svc.name)})
+	if err := req.Build(); err != nil {
+		return nil, err
+	}
+	creds, err := req.Config.Credentials.Get()
+	if err != nil {
+		return nil, err
+	}
+	region := req.ClientInfo.SigningRegion
+	if region == "" {
+		region = aws.StringValue(req.Config.Region)
+	}
+
+	t := now().UTC()
+	date := t.Format("20060102")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, region)
+	fields := map[string]string{
+		"key":              p.KeyPrefix + "${filename}",
+		"x-amz-algorithm":  "AWS4-HMAC-SHA256",
+		"x-amz-credential": creds.AccessKeyID + "/" + scope,
+		"x-amz-date":       t.Format("20060102T150405Z"),
+	}
+	if svc.acl != nil {
+		fields["acl"] = *svc.acl
+	}
+	if creds.SessionToken != "" {
+		fields["x-amz-security-token"] = creds.SessionToken
+	}
+	h := svc.sse.headers()
+	if h.algorithm != nil {
+		fields["x-amz-server-side-encryption"] = *h.algorithm
+	}
+	if h.kmsKeyID != nil {
+		fields["x-amz-server-side-encryption-aws-kms-key-id"] = *h.kmsKeyID
+	}
+	if h.kmsContext != nil {
+		fields["x-amz-server-side-encryption-context"] = *h.kmsContext
+	}
+	if h.bucketKey != nil {
+		fields["x-amz-server-side-encryption-bucket-key-enabled"] = "true"
+	}
+
+	conditions := []interface{}{
+		map[string]string{"bucket": svc.name},
+		[]string{"starts-with", "$key", p.KeyPrefix},
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		if name != "key" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		conditions = append(conditions, map[string]string{name: fields[name]})
+	}
+	if p.MaxSize > 0 {
+		conditions = append(conditions, []interface{}{"content-length-range", p.MinSize, p.MaxSize})
+	}
+	if p.ContentTypePrefix != "" {
+		conditions = append(conditions, []string{"starts-with", "$Content-Type", p.ContentTypePrefix})
+	}
+	policy, err := json.Marshal(map[string]interface{}{
+		"expiration": t.Add(p.TTL).Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	fields["policy"] = base64.StdEncoding.EncodeToString(policy)
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	for _, s := range []string{region, "s3", "aws4_request", fields["policy"]} {
+		key = hmacSHA256(key, s)
+	}
+	fields["x-amz-signature"] = hex.EncodeToString(key)
+
+	u := *req.HTTPRequest.URL
+	u.RawQuery = ""
+	return &PresignedPost{URL: u.String(), Fields: fields}, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,160 @@
+package s3
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func presignService(t *testing.T) *Service {
+	sess, err := session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", "TOKEN"),
+		Region:      aws.String("us-west-2"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newWithSvc(testBucket, s3.New(sess))
+}
+
+func TestService_PresignPut(t *testing.T) {
+	svc := presignService(t)
+	svc.SetDefaultTags(map[string]string{"team": "data"})
+	svc.SetStorageClass(aws.String(s3.StorageClassStandardIa))
+	signed, header, err := svc.PresignPut("uploads/a.csv", time.Hour, "text/csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Host != testBucket+".s3.us-west-2.amazonaws.com" || u.Path != "/uploads/a.csv" {
+		t.Errorf("unexpected url: %s", signed)
+	}
+	if u.Query().Get("X-Amz-Expires") != "3600" {
+		t.Errorf(`expires: want: "3600", got: "%s"`, u.Query().Get("X-Amz-Expires"))
+	}
+	for name, want := range map[string]string{
+		"X-Amz-Acl":                    "bucket-owner-full-control",
+		"X-Amz-Server-Side-Encryption": "AES256",
+		"X-Amz-Storage-Class":          s3.StorageClassStandardIa,
+		"X-Amz-Tagging":                "team=data",
+		"Content-Type":                 "text/csv",
+	} {
+		if got := header.Get(name); got != want {
+			t.Errorf(`header %s: want: "%s", got: "%s"`, name, want, got)
+		}
+	}
+}
+
+func TestService_PresignGet(t *testing.T) {
+	svc := presignService(t)
+	key := []byte("0123456789abcdef0123456789abcdef")
+	svc.SetEncryption(&Encryption{CustomerKey: key})
+	signed, header, err := svc.PresignGet("reports/2020.csv", 15*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Host != testBucket+".s3.us-west-2.amazonaws.com" || u.Path != "/reports/2020.csv" {
+		t.Errorf("unexpected url: %s", signed)
+	}
+	if u.Query().Get("X-Amz-Expires") != "900" {
+		t.Errorf(`expires: want: "900", got: "%s"`, u.Query().Get("X-Amz-Expires"))
+	}
+	sum := md5.Sum(key)
+	for name, want := range map[string]string{
+		"X-Amz-Server-Side-Encryption-Customer-Algorithm": "AES256",
+		"X-Amz-Server-Side-Encryption-Customer-Key":       base64.StdEncoding.EncodeToString(key),
+		"X-Amz-Server-Side-Encryption-Customer-Key-Md5":   base64.StdEncoding.EncodeToString(sum[:]),
+	} {
+		if got := header.Get(name); got != want {
+			t.Errorf(`header %s: want: "%s", got: "%s"`, name, want, got)
+		}
+	}
+	if signedHeaders := u.Query().Get("X-Amz-SignedHeaders"); !strings.Contains(signedHeaders, "x-amz-server-side-encryption-customer-key") {
+		t.Errorf("customer key not signed: %s", signedHeaders)
+	}
+}
+
+func TestService_PresignPost(t *testing.T) {
+	defer func(orig func() time.Time) { now = orig }(now)
+	now = func() time.Time { return time.Date(2020, 6, 1, 12, 30, 0, 0, time.UTC) }
+	svc := presignService(t)
+	post, err := svc.PresignPost(&PostPolicy{KeyPrefix: "uploads/", MaxSize: 1 << 20, TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.URL != "https://"+testBucket+".s3.us-west-2.amazonaws.com/" {
+		t.Errorf("unexpected url: %s", post.URL)
+	}
+	want := map[string]string{
+		"key":                          "uploads/${filename}",
+		"acl":                          "bucket-owner-full-control",
+		"x-amz-algorithm":              "AWS4-HMAC-SHA256",
+		"x-amz-credential":             "AKID/20200601/us-west-2/s3/aws4_request",
+		"x-amz-date":                   "20200601T123000Z",
+		"x-amz-security-token":         "TOKEN",
+		"x-amz-server-side-encryption": "AES256",
+		"policy":                       "",
+		"x-amz-signature":              "",
+	}
+	for name := range post.Fields {
+		if _, ok := want[name]; !ok {
+			t.Errorf("unexpected field %s", name)
+		}
+	}
+	for name, v := range want {
+		if v != "" && post.Fields[name] != v {
+			t.Errorf(`field %s: want: "%s", got: "%s"`, name, v, post.Fields[name])
+		}
+	}
+	policy, err := base64.StdEncoding.DecodeString(post.Fields["policy"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPolicy := `{"conditions":[{"bucket":"` + testBucket + `"},["starts-with","$key","uploads/"],` +
+		`{"acl":"bucket-owner-full-control"},{"x-amz-algorithm":"AWS4-HMAC-SHA256"},` +
+		`{"x-amz-credential":"AKID/20200601/us-west-2/s3/aws4_request"},{"x-amz-date":"20200601T123000Z"},` +
+		`{"x-amz-security-token":"TOKEN"},{"x-amz-server-side-encryption":"AES256"},` +
+		`["content-length-range",0,1048576]],"expiration":"2020-06-01T13:30:00.000Z"}`
+	if string(policy) != wantPolicy {
+		t.Errorf("policy:\nwant: %s\ngot:  %s", wantPolicy, policy)
+	}
+	if want := "9fda583157113525aa145140411071c7425954accd95560f681a1fa71589471a"; post.Fields["x-amz-signature"] != want {
+		t.Errorf(`signature: want: "%s", got: "%s"`, want, post.Fields["x-amz-signature"])
+	}
+}
+
+func TestService_PresignPostNilPolicy(t *testing.T) {
+	if _, err := presignService(t).PresignPost(nil); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestService_presignTTL(t *testing.T) {
+	svc := presignService(t)
+	for _, ttl := range []time.Duration{0, -time.Minute} {
+		if _, _, err := svc.PresignGet("a.csv", ttl); err == nil {
+			t.Errorf("get with ttl %v: want error", ttl)
+		}
+		if _, _, err := svc.PresignPut("a.csv", ttl, ""); err == nil {
+			t.Errorf("put with ttl %v: want error", ttl)
+		}
+		if _, err := svc.PresignPost(&PostPolicy{KeyPrefix: "uploads/", TTL: ttl}); err == nil {
+			t.Errorf("post with ttl %v: want error", ttl)
+		}
+	}
+}
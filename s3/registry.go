@@ -0,0 +1,161 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/cleardataeng/aidews"
+)
+
+// locationRegion is the region in which bucket locations are looked up.
+const locationRegion = "us-east-1"
+
+// BucketAccess is how a Registry reaches a bucket.
+type BucketAccess struct {
+	// Region of the bucket. When empty it is found with GetBucketLocation.
+	Region string
+
+	// RoleARN is assumed to access the bucket. When empty the default
+	// credentials are used.
+	RoleARN string
+}
+
+// Registry provides Services for buckets in any region and account, according
+// to the access configured for their names. Services are created when first
+// requested and reused after, and clients are shared by buckets with the same
+// region and role.
+type Registry struct {
+	mu        sync.Mutex
+	rules     map[string]BucketAccess
+	services  map[string]*Service
+	clients   map[BucketAccess]s3iface.S3API
+	configure func(*Service)
+
+	// newClient returns a client for the access; replaced in tests.
+	newClient func(BucketAccess) s3iface.S3API
+}
+
+// NewRegistry returns a pointer to a new Registry. Buckets without configured
+// access are reached with the default credentials in their own region.
+func NewRegistry() *Registry {
+	return &Registry{
+		rules:    map[string]BucketAccess{},
+		services: map[string]*Service{},
+		clients:  map[BucketAccess]s3iface.S3API{},
+		newClient: func(a BucketAccess) s3iface.S3API {
+			var region, roleARN *string
+			if a.Region != "" {
+				region = aws.String(a.Region)
+			}
+			if a.RoleARN != "" {
+				roleARN = aws.String(a.RoleARN)
+			}
+			return s3.New(aidews.Session(region, roleARN))
+		},
+	}
+}
+
+// Add configures the access for the bucket named by pattern, or for every
+// bucket with a prefix when pattern ends in "*", e.g. "logs-*". Exact names
+// take precedence over prefixes, and longer prefixes over shorter ones; "*"
+// sets the access of buckets matching nothing else. Services already created
+// are not changed.
+func (r *Registry) Add(pattern string, access BucketAccess) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules[pattern] = access
+}
+
+// Configure sets a function called with each Service the Registry creates, to
+// change its defaults, e.g. with SetEncryption.
+func (r *Registry) Configure(fn func(*Service)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.configure = fn
+}
+
+// Service returns the Service for the bucket, creating it on first use.
+// See ServiceWithContext.
+func (r *Registry) Service(bucket string) (*Service, error) {
+	return r.ServiceWithContext(context.TODO(), bucket)
+}
+
+// ServiceWithContext returns the Service for the bucket, creating it on first
+// use. The region of the bucket is looked up when not configured, using its
+// role.
+func (r *Registry) ServiceWithContext(ctx context.Context, bucket string) (*Service, error) {
+	r.mu.Lock()
+	if svc, ok := r.services[bucket]; ok {
+		r.mu.Unlock()
+		return svc, nil
+	}
+	access := r.access(bucket)
+	r.mu.Unlock()
+
+	if access.Region == "" {
+		region, err := r.bucketRegion(ctx, bucket, access.RoleARN)
+		if err != nil {
+			return nil, err
+		}
+		access.Region = region
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if svc, ok := r.services[bucket]; ok {
+		return svc, nil // created concurrently
+	}
+	svc := newWithSvc(bucket, r.client(access))
+	if r.configure != nil {
+		r.configure(svc)
+	}
+	r.services[bucket] = svc
+	return svc, nil
+}
+
+// access returns the access of the rule best matching the bucket.
+// r.mu must be held.
+func (r *Registry) access(bucket string) BucketAccess {
+	if a, ok := r.rules[bucket]; ok {
+		return a
+	}
+	best, found := "", false
+	var access BucketAccess
+	for pattern, a := range r.rules {
+		prefix := strings.TrimSuffix(pattern, "*")
+		if prefix == pattern || !strings.HasPrefix(bucket, prefix) {
+			continue
+		}
+		if !found || len(prefix) > len(best) {
+			best, found, access = prefix, true, a
+		}
+	}
+	return access
+}
+
+// client returns the shared client for the access. r.mu must be held.
+func (r *Registry) client(a BucketAccess) s3iface.S3API {
+	c, ok := r.clients[a]
+	if !ok {
+		c = r.newClient(a)
+		r.clients[a] = c
+	}
+	return c
+}
+
+// bucketRegion looks up the region of the bucket with GetBucketLocation.
+func (r *Registry) bucketRegion(ctx context.Context, bucket, roleARN string) (string, error) {
+	r.mu.Lock()
+	c := r.client(BucketAccess{Region: locationRegion, RoleARN: roleARN})
+	r.mu.Unlock()
+	res, err := c.GetBucketLocationWithContext(ctx, &s3.GetBucketLocationInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		return "", fmt.Errorf("bucket %s location: %w", bucket, err)
+	}
+	return s3.NormalizeBucketLocation(aws.StringValue(res.LocationConstraint)), nil
+}
@@ -0,0 +1,77 @@
+package s3
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/cleardataeng/aidews/s3/extmocks/github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRegistry_Service(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	locator := mock_s3iface.NewMockS3API(ctrl)
+	locator.EXPECT().GetBucketLocationWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.GetBucketLocationInput, _ ...request.Option) (*s3.GetBucketLocationOutput, error) {
+			if got := aws.StringValue(in.Bucket); got != "logs-eu" {
+				t.Errorf(`bucket: want: "logs-eu", got: "%s"`, got)
+			}
+			return &s3.GetBucketLocationOutput{LocationConstraint: aws.String("EU")}, nil
+		},
+	)
+
+	reg := NewRegistry()
+	var created []BucketAccess
+	reg.newClient = func(a BucketAccess) s3iface.S3API {
+		created = append(created, a)
+		if a.Region == locationRegion {
+			return locator
+		}
+		return mock_s3iface.NewMockS3API(ctrl)
+	}
+	reg.Add("*", BucketAccess{Region: "us-west-2"})
+	reg.Add("logs-*", BucketAccess{RoleARN: "arn:aws:iam::111111111111:role/logs"})
+	reg.Add("logs-audit-*", BucketAccess{Region: "us-east-2", RoleARN: "arn:aws:iam::222222222222:role/audit"})
+	reg.Add("logs-us", BucketAccess{Region: "us-west-2", RoleARN: "arn:aws:iam::111111111111:role/logs"})
+	reg.Configure(func(svc *Service) { svc.SetStorageClass(aws.String(s3.StorageClassStandardIa)) })
+
+	tests := []struct {
+		bucket string
+		want   BucketAccess
+	}{
+		{"movement-keys", BucketAccess{Region: "us-west-2"}},
+		{"other-keys", BucketAccess{Region: "us-west-2"}},
+		{"logs-eu", BucketAccess{Region: "eu-west-1", RoleARN: "arn:aws:iam::111111111111:role/logs"}},
+		{"logs-audit-2023", BucketAccess{Region: "us-east-2", RoleARN: "arn:aws:iam::222222222222:role/audit"}},
+		{"logs-us", BucketAccess{Region: "us-west-2", RoleARN: "arn:aws:iam::111111111111:role/logs"}},
+	}
+	clients := map[BucketAccess]s3iface.S3API{}
+	for _, tt := range tests {
+		svc, err := reg.ServiceWithContext(ctx, tt.bucket)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if svc.name != tt.bucket {
+			t.Errorf(`name: want: "%s", got: "%s"`, tt.bucket, svc.name)
+		}
+		if got := aws.StringValue(svc.storageClass); got != s3.StorageClassStandardIa {
+			t.Errorf(`%s storage class: want: "%s", got: "%s"`, tt.bucket, s3.StorageClassStandardIa, got)
+		}
+		if c, ok := clients[tt.want]; ok && c != svc.svc {
+			t.Errorf(`%s: client for %+v not shared`, tt.bucket, tt.want)
+		}
+		clients[tt.want] = svc.svc
+		if again, _ := reg.ServiceWithContext(ctx, tt.bucket); again != svc {
+			t.Errorf(`%s: service not cached`, tt.bucket)
+		}
+	}
+	// the location client, then one per distinct access
+	if len(created) != 5 {
+		t.Errorf(`clients created: want: 5, got: %d: %+v`, len(created), created)
+	}
+}
@@ -1,18 +1,115 @@
 package s3iface
 
 import (
+	"context"
 	"io"
+	"net/http"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/cleardataeng/aidews/policy"
+	aide "github.com/cleardataeng/aidews/s3"
 )
 
 // Service reads and writes to the given bucket.
 type Service interface {
+	Copy(string, string, string) (*s3.CopyObjectOutput, error)
+	CopyWithContext(context.Context, string, string, string) (*s3.CopyObjectOutput, error)
+	Delete(string) (*s3.DeleteObjectOutput, error)
+	DeleteWithContext(context.Context, string) (*s3.DeleteObjectOutput, error)
+	DeleteMany([]string) error
+	DeleteManyWithContext(context.Context, []string) error
+	DeletePrefix(string) error
+	DeletePrefixWithContext(context.Context, string) error
+	DeleteVersion(string, string, bool) error
+	DeleteVersionWithContext(context.Context, string, string, bool) error
+	Move(string, string, string) (*s3.CopyObjectOutput, error)
+	MoveWithContext(context.Context, string, string, string) (*s3.CopyObjectOutput, error)
+	PresignGet(string, time.Duration) (string, http.Header, error)
+	PresignPost(*aide.PostPolicy) (*aide.PresignedPost, error)
+	PresignPut(string, time.Duration, string) (string, http.Header, error)
+	EnsureBucket(*aide.BucketConfig) ([]string, error)
+	EnsureBucketWithContext(context.Context, *aide.BucketConfig) ([]string, error)
+	FS(string) *aide.BucketFS
+	GetBucketCORS() ([]aide.CORSRule, error)
+	GetBucketCORSWithContext(context.Context) ([]aide.CORSRule, error)
+	GetBucketEncryption() (*aide.Encryption, error)
+	GetBucketEncryptionWithContext(context.Context) (*aide.Encryption, error)
+	GetBucketLifecycle() ([]aide.LifecycleRule, error)
+	GetBucketLifecycleWithContext(context.Context) ([]aide.LifecycleRule, error)
+	GetBucketPolicy() (*policy.IAMPolicy, error)
+	GetBucketPolicyWithContext(context.Context) (*policy.IAMPolicy, error)
+	GetBucketVersioning() (bool, error)
+	GetBucketVersioningWithContext(context.Context) (bool, error)
+	GetLegalHold(string, string) (bool, error)
+	GetLegalHoldWithContext(context.Context, string, string) (bool, error)
+	GetPublicAccessBlock() (*aide.PublicAccessBlock, error)
+	GetPublicAccessBlockWithContext(context.Context) (*aide.PublicAccessBlock, error)
+	GetRetention(string, string) (*aide.Retention, error)
+	GetRetentionWithContext(context.Context, string, string) (*aide.Retention, error)
+	GetTags(string) (map[string]string, error)
+	GetTagsWithContext(context.Context, string) (map[string]string, error)
+	Head(string) (*aide.ObjectInfo, error)
+	HeadWithContext(context.Context, string) (*aide.ObjectInfo, error)
 	Put(string, io.Reader) (*s3.PutObjectOutput, error)
+	PutWithOptions(string, io.Reader, *aide.PutOptions) (*s3.PutObjectOutput, error)
+	PutWithOptionsWithContext(context.Context, string, io.Reader, *aide.PutOptions) (*s3.PutObjectOutput, error)
+	Upload(string, io.Reader) (*s3manager.UploadOutput, error)
+	UploadWithContext(context.Context, string, io.Reader) (*s3manager.UploadOutput, error)
+	PutMarshal(string, interface{}) (*s3.PutObjectOutput, error)
 	Read(string) (*io.ReadCloser, error)
+	ReadDecoded(string) (io.ReadCloser, error)
+	ReadDecodedWithContext(context.Context, string) (io.ReadCloser, error)
+	ReadEvent([]aide.EventRecord, func(aide.EventRecord, io.Reader) error) error
+	ReadEventWithContext(context.Context, []aide.EventRecord, func(aide.EventRecord, io.Reader) error) error
+	ReadJSONArray(string, string) (*aide.RecordIterator, error)
+	ReadJSONArrayWithContext(context.Context, string, string) (*aide.RecordIterator, error)
+	ReadJSONLines(string) (*aide.RecordIterator, error)
+	ReadJSONLinesWithContext(context.Context, string) (*aide.RecordIterator, error)
 	ReadUnmarshal(string, interface{}) error
+	ReadVersion(string, string) (*io.ReadCloser, error)
+	ReadVersionWithContext(context.Context, string, string) (*io.ReadCloser, error)
+	RestoreVersion(string, string) (*s3.CopyObjectOutput, error)
+	RestoreVersionWithContext(context.Context, string, string) (*s3.CopyObjectOutput, error)
+	Select(string, string, aide.SelectFormat, aide.SelectFormat) (*aide.SelectIterator, error)
+	SelectWithContext(context.Context, string, string, aide.SelectFormat, aide.SelectFormat) (*aide.SelectIterator, error)
+	SelectUnmarshal(string, string, aide.SelectFormat, interface{}) error
+	SelectUnmarshalWithContext(context.Context, string, string, aide.SelectFormat, interface{}) error
 	SetACL(*string)
+	SetBucketCORS([]aide.CORSRule) error
+	SetBucketCORSWithContext(context.Context, []aide.CORSRule) error
+	SetBucketEncryption(*aide.Encryption) error
+	SetBucketEncryptionWithContext(context.Context, *aide.Encryption) error
+	SetBucketLifecycle([]aide.LifecycleRule) error
+	SetBucketLifecycleWithContext(context.Context, []aide.LifecycleRule) error
+	SetBucketPolicy(*policy.IAMPolicy) error
+	SetBucketPolicyWithContext(context.Context, *policy.IAMPolicy) error
+	SetBucketVersioning(bool) error
+	SetBucketVersioningWithContext(context.Context, bool) error
+	SetCodec(aide.Codec)
+	SetDefaultTags(map[string]string)
+	SetEncryption(*aide.Encryption)
+	SetLegalHold(string, string, bool) error
+	SetLegalHoldWithContext(context.Context, string, string, bool) error
+	SetPublicAccessBlock(*aide.PublicAccessBlock) error
+	SetPublicAccessBlockWithContext(context.Context, *aide.PublicAccessBlock) error
+	SetRetention(string, string, *aide.Retention, bool) error
+	SetRetentionWithContext(context.Context, string, string, *aide.Retention, bool) error
 	SetSSE(*string)
+	SetStorageClass(*string)
+	SetTags(string, map[string]string) error
+	SetTagsWithContext(context.Context, string, map[string]string) error
+	List(*aide.ListInput, func(aide.ObjectSummary) bool) error
+	ListWithContext(context.Context, *aide.ListInput, func(aide.ObjectSummary) bool) error
+	ListDir(string, func(aide.ObjectSummary) bool) error
+	ListDirWithContext(context.Context, string, func(aide.ObjectSummary) bool) error
+	ListVersions(string, func(aide.ObjectVersion) bool) error
+	ListVersionsWithContext(context.Context, string, func(aide.ObjectVersion) bool) error
+	Sync(string, string, *aide.SyncOptions) ([]aide.SyncAction, error)
+	SyncWithContext(context.Context, string, string, *aide.SyncOptions) ([]aide.SyncAction, error)
 	ListObjectsKeysV2Pages(*s3.ListObjectsV2Input) ([]string, bool, error)
 	ListObjectsV2Input() *s3.ListObjectsV2Input
 }
+
+var _ Service = (*aide.Service)(nil) // test that the aide satisfies the interface
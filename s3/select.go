@@ -0,0 +1,270 @@
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// SelectFormat is the serialization of records read or returned by S3 Select.
+type SelectFormat int
+
+const (
+	// SelectCSV is CSV with a header row, so columns can be named in queries.
+	SelectCSV SelectFormat = iota
+
+	// SelectCSVNoHeader is CSV without a header row; columns are _1, _2, ...
+	SelectCSVNoHeader
+
+	// SelectJSONLines is one JSON object per line.
+	SelectJSONLines
+
+	// SelectJSONDocument is a single JSON document. Input only.
+	SelectJSONDocument
+
+	// SelectParquet is Apache Parquet. Input only.
+	SelectParquet
+)
+
+// Select runs the SQL expression against the object at the key with S3 Select
+// and returns an iterator over the matching records, which are streamed as
+// they are read.
+// See SelectWithContext.
+func (svc *Service) Select(key, sql string, input, output SelectFormat) (*SelectIterator, error) {
+	return svc.SelectWithContext(context.TODO(), key, sql, input, output)
+}
+
+// SelectWithContext runs the SQL expression against the object at the key with
+// S3 Select and returns an iterator over the matching records, which are
+// streamed as they are read. Objects with a .gz or .bz2 key suffix are
+// decompressed by S3. Output may be SelectCSV or SelectJSONLines. The iterator
+// must be closed.
+//
+// Example:
+//
+//	it, err := svc.SelectWithContext(ctx, "exports/users.csv", "SELECT * FROM s3object s WHERE s.state = 'CA'", SelectCSV, SelectJSONLines)
+//	if err != nil {
+//		return err
+//	}
+//	defer it.Close()
+//	for it.Next() {
+//		var u User
+//		if err := it.Decode(&u); err != nil {
+//			return err
+//		}
+//	}
+//	return it.Err()
+func (svc *Service) SelectWithContext(ctx context.Context, key, sql string, input, output SelectFormat) (*SelectIterator, error) {
+	in := &s3.SelectObjectContentInput{
+		Bucket:         aws.String(svc.name),
+		Expression:     aws.String(sql),
+		ExpressionType: aws.String(s3.ExpressionTypeSql),
+		Key:            aws.String(key),
+	}
+	var err error
+	if in.InputSerialization, err = selectInput(key, input); err != nil {
+		return nil, err
+	}
+	if in.OutputSerialization, err = selectOutput(output); err != nil {
+		return nil, err
+	}
+	svc.sse.applySelect(in)
+	res, err := svc.svc.SelectObjectContentWithContext(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	r := &selectReader{ctx: ctx, stream: res.EventStream}
+	it := &SelectIterator{stream: res.EventStream}
+	if output == SelectJSONLines {
+		it.lines = bufio.NewReader(r)
+	} else {
+		it.csv = csv.NewReader(r)
+		it.csv.FieldsPerRecord = -1
+	}
+	return it, nil
+}
+
+// SelectUnmarshal runs the SQL expression against the object at the key with
+// S3 Select and unmarshals the matching records into out, which must be a
+// pointer to a slice.
+// See SelectUnmarshalWithContext.
+func (svc *Service) SelectUnmarshal(key, sql string, input SelectFormat, out interface{}) error {
+	return svc.SelectUnmarshalWithContext(context.TODO(), key, sql, input, out)
+}
+
+// SelectUnmarshalWithContext runs the SQL expression against the object at the
+// key with S3 Select and unmarshals the matching records into out, which must
+// be a pointer to a slice. Only the selected records are transferred. See
+// SelectWithContext.
+func (svc *Service) SelectUnmarshalWithContext(ctx context.Context, key, sql string, input SelectFormat, out interface{}) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("SelectUnmarshal: out must be a pointer to a slice, got %T", out)
+	}
+	it, err := svc.SelectWithContext(ctx, key, sql, input, SelectJSONLines)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	slice := v.Elem()
+	for it.Next() {
+		elem := reflect.New(slice.Type().Elem())
+		if err := it.Decode(elem.Interface()); err != nil {
+			return err
+		}
+		slice.Set(reflect.Append(slice, elem.Elem()))
+	}
+	return it.Err()
+}
+
+func selectInput(key string, f SelectFormat) (*s3.InputSerialization, error) {
+	in := &s3.InputSerialization{}
+	switch f {
+	case SelectCSV:
+		in.CSV = &s3.CSVInput{FileHeaderInfo: aws.String(s3.FileHeaderInfoUse)}
+	case SelectCSVNoHeader:
+		in.CSV = &s3.CSVInput{FileHeaderInfo: aws.String(s3.FileHeaderInfoNone)}
+	case SelectJSONLines:
+		in.JSON = &s3.JSONInput{Type: aws.String(s3.JSONTypeLines)}
+	case SelectJSONDocument:
+		in.JSON = &s3.JSONInput{Type: aws.String(s3.JSONTypeDocument)}
+	case SelectParquet:
+		in.Parquet = &s3.ParquetInput{}
+		return in, nil
+	default:
+		return nil, fmt.Errorf("unsupported select input format %d", f)
+	}
+	switch {
+	case strings.HasSuffix(key, ".gz"):
+		in.CompressionType = aws.String(s3.CompressionTypeGzip)
+	case strings.HasSuffix(key, ".bz2"):
+		in.CompressionType = aws.String(s3.CompressionTypeBzip2)
+	}
+	return in, nil
+}
+
+func selectOutput(f SelectFormat) (*s3.OutputSerialization, error) {
+	switch f {
+	case SelectCSV, SelectCSVNoHeader:
+		return &s3.OutputSerialization{CSV: &s3.CSVOutput{}}, nil
+	case SelectJSONLines:
+		return &s3.OutputSerialization{JSON: &s3.JSONOutput{RecordDelimiter: aws.String("\n")}}, nil
+	}
+	return nil, fmt.Errorf("unsupported select output format %d", f)
+}
+
+// SelectIterator steps through the records returned by Select.
+type SelectIterator struct {
+	stream *s3.SelectObjectContentEventStream
+	lines  *bufio.Reader
+	csv    *csv.Reader
+	record []byte
+	fields []string
+	err    error
+}
+
+// Next advances to the next record, returning false when there are no more
+// records or an error occurred. See Err.
+func (it *SelectIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.csv != nil {
+		it.fields, it.err = it.csv.Read()
+		return it.err == nil
+	}
+	for {
+		line, err := it.lines.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			it.record = line
+			if err == io.EOF {
+				err = nil
+			}
+			it.err = err
+			return err == nil
+		}
+		if err != nil {
+			it.err = err
+			return false
+		}
+	}
+}
+
+// Record returns the current JSON record.
+func (it *SelectIterator) Record() []byte {
+	return it.record
+}
+
+// Decode unmarshals the current JSON record into v.
+func (it *SelectIterator) Decode(v interface{}) error {
+	if it.lines == nil {
+		return errors.New("select: Decode requires JSON output")
+	}
+	return json.Unmarshal(it.record, v)
+}
+
+// Fields returns the current CSV record.
+func (it *SelectIterator) Fields() []string {
+	return it.fields
+}
+
+// Err returns the error that stopped iteration, if any.
+func (it *SelectIterator) Err() error {
+	if it.err == io.EOF {
+		return nil
+	}
+	return it.err
+}
+
+// Close stops the select and releases its connection.
+func (it *SelectIterator) Close() error {
+	return it.stream.Close()
+}
+
+// selectReader reads the record payloads from a select event stream.
+type selectReader struct {
+	ctx    context.Context
+	stream *s3.SelectObjectContentEventStream
+	buf    []byte
+	ended  bool
+}
+
+func (r *selectReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.ended {
+			return 0, io.EOF
+		}
+		var ev s3.SelectObjectContentEventStreamEvent
+		var ok bool
+		select {
+		case ev, ok = <-r.stream.Events():
+		case <-r.ctx.Done():
+			return 0, r.ctx.Err()
+		}
+		if !ok {
+			if err := r.stream.Err(); err != nil {
+				return 0, err
+			}
+			return 0, io.ErrUnexpectedEOF // the stream closed before its end event
+		}
+		switch e := ev.(type) {
+		case *s3.RecordsEvent:
+			r.buf = e.Payload
+		case *s3.EndEvent:
+			r.ended = true
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
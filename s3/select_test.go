@@ -0,0 +1,99 @@
+package s3
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cleardataeng/aidews/s3/extmocks/github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+)
+
+// selectEvents is a mock select event stream reader.
+type selectEvents struct {
+	ch chan s3.SelectObjectContentEventStreamEvent
+}
+
+func newSelectEvents(events ...s3.SelectObjectContentEventStreamEvent) *selectEvents {
+	ch := make(chan s3.SelectObjectContentEventStreamEvent, len(events))
+	for _, e := range events {
+		ch <- e
+	}
+	close(ch)
+	return &selectEvents{ch: ch}
+}
+
+func (e *selectEvents) Events() <-chan s3.SelectObjectContentEventStreamEvent { return e.ch }
+func (e *selectEvents) Close() error                                          { return nil }
+func (e *selectEvents) Err() error                                            { return nil }
+
+func expectSelect(t *testing.T, s3Mock *mock_s3iface.MockS3API, reader s3.SelectObjectContentEventStreamReader) {
+	s3Mock.EXPECT().SelectObjectContentWithContext(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.SelectObjectContentInput, _ ...request.Option) (*s3.SelectObjectContentOutput, error) {
+			if got := aws.StringValue(in.InputSerialization.CompressionType); got != s3.CompressionTypeGzip {
+				t.Errorf(`compression: want: "%s", got: "%s"`, s3.CompressionTypeGzip, got)
+			}
+			if got := aws.StringValue(in.InputSerialization.CSV.FileHeaderInfo); got != s3.FileHeaderInfoUse {
+				t.Errorf(`header info: want: "%s", got: "%s"`, s3.FileHeaderInfoUse, got)
+			}
+			return &s3.SelectObjectContentOutput{
+				EventStream: s3.NewSelectObjectContentEventStream(func(es *s3.SelectObjectContentEventStream) {
+					es.Reader = reader
+					es.StreamCloser = reader
+				}),
+			}, nil
+		},
+	)
+}
+
+func TestService_SelectUnmarshal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	// records are split across payloads at arbitrary points
+	expectSelect(t, s3Mock, newSelectEvents(
+		&s3.RecordsEvent{Payload: []byte(`{"slug":"xkcd","title":"Some guy"}` + "\n" + `{"slug":"wa`)},
+		&s3.StatsEvent{},
+		&s3.RecordsEvent{Payload: []byte(`sd","title":"gaming"}` + "\n")},
+		&s3.EndEvent{},
+	))
+
+	var got []doc
+	svc := newWithSvc(testBucket, s3Mock)
+	if err := svc.SelectUnmarshalWithContext(ctx, "export.csv.gz", "SELECT * FROM s3object", SelectCSV, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []doc{{Slug: "xkcd", Title: "Some guy"}, {Slug: "wasd", Title: "gaming"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("docs: wanted: %+v, got: %+v", want, got)
+	}
+}
+
+func TestService_SelectTruncated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	expectSelect(t, s3Mock, newSelectEvents(
+		&s3.RecordsEvent{Payload: []byte("a,b\n")},
+	))
+
+	svc := newWithSvc(testBucket, s3Mock)
+	it, err := svc.SelectWithContext(ctx, "export.csv.gz", "SELECT * FROM s3object", SelectCSV, SelectCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	var rows [][]string
+	for it.Next() {
+		rows = append(rows, it.Fields())
+	}
+	if want := [][]string{{"a", "b"}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows: wanted: %v, got: %v", want, rows)
+	}
+	if it.Err() == nil {
+		t.Error("expected an error for a stream without an end event")
+	}
+}
@@ -0,0 +1,227 @@
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// suffixEncodings are the encodings recognised by key suffix, for objects
+// stored without a Content-Encoding. The magic bytes guard against content
+// the HTTP client has already decompressed.
+var suffixEncodings = []struct {
+	suffix, name string
+	magic        []byte
+}{
+	{".gz", "gzip", []byte{0x1f, 0x8b}},
+	{".zst", "zstd", []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// ReadDecoded gets the object from the bucket at the key as a stream of its
+// decompressed content.
+// See ReadDecodedWithContext.
+func (svc *Service) ReadDecoded(key string) (io.ReadCloser, error) {
+	return svc.ReadDecodedWithContext(context.TODO(), key)
+}
+
+// ReadDecodedWithContext gets the object from the bucket at the key as a
+// stream of its decompressed content. The encoding is taken from the
+// Content-Encoding of the object, or else from a .gz or .zst key suffix. The
+// stream stops with the context error if ctx is done, and must be closed.
+func (svc *Service) ReadDecodedWithContext(ctx context.Context, key string) (io.ReadCloser, error) {
+	res, err := svc.getObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	body := bufio.NewReader(&ctxReader{ctx: ctx, r: res.Body})
+	name := aws.StringValue(res.ContentEncoding)
+	if name == "" {
+		for _, s := range suffixEncodings {
+			if !strings.HasSuffix(key, s.suffix) {
+				continue
+			}
+			if magic, _ := body.Peek(len(s.magic)); bytes.Equal(magic, s.magic) {
+				name = s.name
+			}
+			break
+		}
+	}
+	if name == "" || name == "identity" {
+		return readCloser{Reader: body, closers: []io.Closer{res.Body}}, nil
+	}
+	e, ok := lookupEncoding(name)
+	if !ok {
+		res.Body.Close()
+		return nil, fmt.Errorf("unsupported content encoding %q", name)
+	}
+	dr, err := e.NewReader(body)
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	return readCloser{Reader: dr, closers: []io.Closer{dr, res.Body}}, nil
+}
+
+// ReadJSONLines returns an iterator over the JSON values of the object at the
+// key, such as a JSON lines log, decompressing it as ReadDecoded does.
+// See ReadJSONLinesWithContext.
+func (svc *Service) ReadJSONLines(key string) (*RecordIterator, error) {
+	return svc.ReadJSONLinesWithContext(context.TODO(), key)
+}
+
+// ReadJSONLinesWithContext returns an iterator over the JSON values of the
+// object at the key, such as a JSON lines log, decompressing it as ReadDecoded
+// does.
+func (svc *Service) ReadJSONLinesWithContext(ctx context.Context, key string) (*RecordIterator, error) {
+	r, err := svc.ReadDecodedWithContext(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordIterator{body: r, dec: json.NewDecoder(r)}, nil
+}
+
+// ReadJSONArray returns an iterator over the elements of a JSON array in the
+// object at the key, decompressing it as ReadDecoded does.
+// See ReadJSONArrayWithContext.
+func (svc *Service) ReadJSONArray(key, field string) (*RecordIterator, error) {
+	return svc.ReadJSONArrayWithContext(context.TODO(), key, field)
+}
+
+// ReadJSONArrayWithContext returns an iterator over the elements of a JSON
+// array in the object at the key, decompressing it as ReadDecoded does. With
+// an empty field the object must be a top-level array; otherwise it must be an
+// object and the array is its member named field, e.g. "Records" for
+// CloudTrail logs. Only one element is held in memory at a time.
+func (svc *Service) ReadJSONArrayWithContext(ctx context.Context, key, field string) (*RecordIterator, error) {
+	r, err := svc.ReadDecodedWithContext(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	it := &RecordIterator{body: r, dec: json.NewDecoder(r)}
+	if err := it.openArray(field); err != nil {
+		r.Close()
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	return it, nil
+}
+
+// RecordIterator steps through the JSON records of an object.
+type RecordIterator struct {
+	body   io.ReadCloser
+	dec    *json.Decoder
+	array  bool
+	record json.RawMessage
+	err    error
+}
+
+// openArray advances the decoder into the array named field, or the top-level
+// array when field is empty.
+func (it *RecordIterator) openArray(field string) error {
+	if field != "" {
+		if err := expectDelim(it.dec, '{'); err != nil {
+			return err
+		}
+		for {
+			if !it.dec.More() {
+				return fmt.Errorf("no %q member", field)
+			}
+			tok, err := it.dec.Token()
+			if err != nil {
+				return err
+			}
+			if tok == field {
+				break
+			}
+			var skip json.RawMessage
+			if err := it.dec.Decode(&skip); err != nil {
+				return err
+			}
+		}
+	}
+	it.array = true
+	return expectDelim(it.dec, '[')
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != want {
+		return fmt.Errorf("expected %v, got %v", want, tok)
+	}
+	return nil
+}
+
+// Next advances to the next record, returning false when there are no more
+// records or an error occurred. See Err.
+func (it *RecordIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.array && !it.dec.More() {
+		it.err = io.EOF
+		return false
+	}
+	it.record = nil
+	it.err = it.dec.Decode(&it.record)
+	return it.err == nil
+}
+
+// Record returns the current record.
+func (it *RecordIterator) Record() json.RawMessage {
+	return it.record
+}
+
+// Decode unmarshals the current record into v.
+func (it *RecordIterator) Decode(v interface{}) error {
+	return json.Unmarshal(it.record, v)
+}
+
+// Err returns the error that stopped iteration, if any.
+func (it *RecordIterator) Err() error {
+	if it.err == io.EOF {
+		return nil
+	}
+	return it.err
+}
+
+// Close releases the object stream.
+func (it *RecordIterator) Close() error {
+	return it.body.Close()
+}
+
+// ctxReader fails reads once its context is done.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// readCloser reads from Reader and closes every closer in turn.
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (rc readCloser) Close() error {
+	var first error
+	for _, c := range rc.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,136 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cleardataeng/aidews/s3/extmocks/github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+)
+
+func encodeWith(t *testing.T, e Encoding, content string) []byte {
+	var buf bytes.Buffer
+	w := e.NewWriter(&buf)
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestService_ReadJSONLines(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	// no Content-Encoding, so gzip is detected from the key suffix
+	content := encodeWith(t, Gzip, `{"slug":"xkcd","title":"Some guy"}`+"\n\n"+`{"slug":"wasd","title":"gaming"}`+"\n")
+	s3Mock.EXPECT().GetObjectWithContext(gomock.Any(), gomock.Any()).Return(&s3.GetObjectOutput{
+		Body: ioutil.NopCloser(bytes.NewReader(content)),
+	}, nil)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	it, err := svc.ReadJSONLinesWithContext(ctx, "logs/docs.jsonl.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	var got []doc
+	for it.Next() {
+		var d doc
+		if err := it.Decode(&d); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, d)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []doc{{Slug: "xkcd", Title: "Some guy"}, {Slug: "wasd", Title: "gaming"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(`docs: want: %+v, got: %+v`, want, got)
+	}
+}
+
+func TestService_ReadJSONArray(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	content := encodeWith(t, Zstd, `{"Version":"1.0","Skip":{"Records":[1]},"Records":[{"slug":"xkcd"},{"slug":"wasd"}],"After":true}`)
+	s3Mock.EXPECT().GetObjectWithContext(gomock.Any(), gomock.Any()).Return(&s3.GetObjectOutput{
+		Body:            ioutil.NopCloser(bytes.NewReader(content)),
+		ContentEncoding: aws.String("zstd"),
+	}, nil)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	it, err := svc.ReadJSONArrayWithContext(ctx, "trail.json", "Records")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	var got []string
+	for it.Next() {
+		got = append(got, string(it.Record()))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`{"slug":"xkcd"}`, `{"slug":"wasd"}`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(`records: want: %q, got: %q`, want, got)
+	}
+}
+
+func TestService_ReadDecodedPlainSuffix(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	// content already decompressed in transit is returned as is
+	s3Mock.EXPECT().GetObjectWithContext(gomock.Any(), gomock.Any()).Return(&s3.GetObjectOutput{
+		Body: ioutil.NopCloser(bytes.NewReader([]byte("plain"))),
+	}, nil)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	r, err := svc.ReadDecodedWithContext(ctx, "notes.txt.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "plain" {
+		t.Errorf(`content: want: "plain", got: "%s"`, got)
+	}
+}
+
+func TestService_ReadJSONLinesCanceled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	s3Mock.EXPECT().GetObjectWithContext(gomock.Any(), gomock.Any()).Return(&s3.GetObjectOutput{
+		Body: ioutil.NopCloser(bytes.NewReader([]byte(`{}`))),
+	}, nil)
+
+	cctx, cancel := context.WithCancel(ctx)
+	svc := newWithSvc(testBucket, s3Mock)
+	it, err := svc.ReadJSONLinesWithContext(cctx, "docs.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	cancel()
+	if it.Next() {
+		t.Error("expected no record after cancel")
+	}
+	if err := it.Err(); err != context.Canceled {
+		t.Errorf(`err: want: "%v", got: "%v"`, context.Canceled, err)
+	}
+}
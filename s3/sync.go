@@ -0,0 +1,472 @@
+package s3
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// syncMD5Metadata is the user metadata in which Sync stores the MD5 of the
+// content it uploads, since ETags are not MD5s for multipart or KMS objects.
+const syncMD5Metadata = "md5"
+
+// defaultSyncConcurrency is the number of transfers Sync runs at once by default.
+const defaultSyncConcurrency = 4
+
+// SyncDirection is the direction in which Sync copies.
+type SyncDirection int
+
+const (
+	// SyncUp copies the local directory to the bucket prefix.
+	SyncUp SyncDirection = iota
+
+	// SyncDown copies the bucket prefix to the local directory.
+	SyncDown
+)
+
+// SyncCompare is how Sync decides a file and an object differ.
+type SyncCompare int
+
+const (
+	// CompareSize copies when the sizes differ.
+	CompareSize SyncCompare = iota
+
+	// CompareETag copies when the sizes differ or the MD5 of the file does not
+	// match the ETag. ETags of multipart, SSE-KMS and SSE-C objects are not
+	// MD5s, so those are always copied; use CompareMD5 for them.
+	CompareETag
+
+	// CompareMD5 copies when the sizes differ or the MD5 of the file does not
+	// match the MD5 Sync stored on the object when uploading it. Objects without
+	// a stored MD5 are compared as with CompareETag. Each object of matching
+	// size is read with a HEAD request, Concurrency at a time.
+	CompareMD5
+)
+
+// SyncOp is an operation Sync performs.
+type SyncOp string
+
+// Sync operations.
+const (
+	SyncUpload   SyncOp = "upload"
+	SyncDownload SyncOp = "download"
+	SyncDelete   SyncOp = "delete"
+)
+
+// SyncOptions configures Sync. The zero value uploads new and changed files,
+// compared by size.
+type SyncOptions struct {
+	Direction SyncDirection
+	Compare   SyncCompare
+
+	// Include and Exclude are path.Match patterns selecting files by their
+	// slash separated path relative to the directory and prefix. Patterns
+	// without a slash match the base name. When Include is given, only
+	// matching files are synced; files matching Exclude never are.
+	Include []string
+	Exclude []string
+
+	// Delete removes files or objects at the destination that are not at the
+	// source. Files excluded by the patterns are never deleted.
+	Delete bool
+
+	// Concurrency is the number of transfers, and of comparisons, run at
+	// once; 4 when zero.
+	Concurrency int
+
+	// DryRun plans the sync without copying or deleting anything.
+	DryRun bool
+}
+
+// SyncAction is a change made, or planned in a dry run, by Sync.
+type SyncAction struct {
+	Op SyncOp
+
+	// Key of the object and Path of the local file.
+	Key  string
+	Path string
+
+	// Size of the content copied; zero for deletes.
+	Size int64
+}
+
+// syncEntry is a file or object found by Sync.
+type syncEntry struct {
+	key, path string
+	size      int64
+	etag      string
+}
+
+// Sync copies the files in localDir and the objects under prefix in the
+// direction given by opts, which may be nil, and returns the actions taken.
+// See SyncWithContext.
+func (svc *Service) Sync(localDir, prefix string, opts *SyncOptions) ([]SyncAction, error) {
+	return svc.SyncWithContext(context.TODO(), localDir, prefix, opts)
+}
+
+// SyncWithContext copies the files in localDir and the objects under prefix in
+// the direction given by opts, which may be nil, and returns the actions
+// taken. Objects are keyed by prefix and the slash separated path of the file
+// relative to localDir, so a prefix is usually given with a trailing slash.
+// Uploads are stored with the Service settings. Transfers stop at the first
+// error, and the actions returned are those planned.
+func (svc *Service) SyncWithContext(ctx context.Context, localDir, prefix string, opts *SyncOptions) ([]SyncAction, error) {
+	if opts == nil {
+		opts = &SyncOptions{}
+	}
+	for _, p := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("sync pattern %q: %w", p, err)
+		}
+	}
+	local, err := syncLocal(localDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := svc.syncRemote(ctx, prefix, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	src, dst := local, remote
+	if opts.Direction == SyncDown {
+		src, dst = remote, local
+	}
+	action := func(op SyncOp, rel string, size int64) SyncAction {
+		return SyncAction{Op: op, Key: prefix + rel, Path: filepath.Join(localDir, filepath.FromSlash(rel)), Size: size}
+	}
+	op := SyncUpload
+	if opts.Direction == SyncDown {
+		op = SyncDownload
+	}
+	var copies, deletes []SyncAction
+	var both []string
+	for rel, s := range src {
+		if _, ok := dst[rel]; ok {
+			both = append(both, rel)
+			continue
+		}
+		copies = append(copies, action(op, rel, s.size))
+	}
+	// comparisons may read the file and HEAD the object, so run in the pool
+	same := make([]bool, len(both))
+	err = syncEach(ctx, opts.concurrency(), len(both), func(ctx context.Context, i int) error {
+		var err error
+		same[i], err = svc.syncSame(ctx, local[both[i]], remote[both[i]], opts.Compare)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, rel := range both {
+		if !same[i] {
+			copies = append(copies, action(op, rel, src[rel].size))
+		}
+	}
+	if opts.Delete {
+		for rel := range dst {
+			if _, ok := src[rel]; !ok {
+				deletes = append(deletes, action(SyncDelete, rel, 0))
+			}
+		}
+	}
+	sortSyncActions(copies)
+	sortSyncActions(deletes)
+	actions := append(copies, deletes...)
+	if opts.DryRun {
+		return actions, nil
+	}
+
+	if err := svc.syncTransfer(ctx, copies, opts); err != nil {
+		return actions, err
+	}
+	return actions, svc.syncDelete(ctx, deletes, opts.Direction)
+}
+
+// syncLocal finds the selected files under dir, keyed by relative slash path.
+func syncLocal(dir string, opts *SyncOptions) (map[string]*syncEntry, error) {
+	entries := map[string]*syncEntry{}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == dir && opts.Direction == SyncDown {
+				return filepath.SkipDir // nothing downloaded yet
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !opts.selected(rel) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries[rel] = &syncEntry{path: p, size: info.Size()}
+		return nil
+	})
+	return entries, err
+}
+
+// syncRemote finds the selected objects under prefix, keyed by the key
+// relative to prefix. Folder placeholder objects, ending in a slash, are skipped.
+func (svc *Service) syncRemote(ctx context.Context, prefix string, opts *SyncOptions) (map[string]*syncEntry, error) {
+	entries := map[string]*syncEntry{}
+	var relErr error
+	err := svc.ListWithContext(ctx, &ListInput{Prefix: prefix}, func(obj ObjectSummary) bool {
+		rel := strings.TrimPrefix(obj.Key, prefix)
+		if rel == "" || strings.HasSuffix(rel, "/") || !opts.selected(rel) {
+			return true
+		}
+		if clean := path.Clean(rel); clean != rel || clean == ".." || strings.HasPrefix(clean, "../") || path.IsAbs(clean) {
+			relErr = fmt.Errorf("sync %s: key is not a clean relative path", obj.Key)
+			return false
+		}
+		entries[rel] = &syncEntry{key: obj.Key, size: obj.Size, etag: obj.ETag}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, relErr
+}
+
+// selected reports whether the relative path passes the Include and Exclude patterns.
+func (opts *SyncOptions) selected(rel string) bool {
+	if len(opts.Include) > 0 && !matchAny(opts.Include, rel) {
+		return false
+	}
+	return !matchAny(opts.Exclude, rel)
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		name := rel
+		if !strings.Contains(p, "/") {
+			name = path.Base(rel)
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// syncSame reports whether the file and object have the same content.
+func (svc *Service) syncSame(ctx context.Context, file, obj *syncEntry, compare SyncCompare) (bool, error) {
+	if file.size != obj.size {
+		return false, nil
+	}
+	if compare == CompareSize {
+		return true, nil
+	}
+	sum, err := fileMD5(file.path)
+	if err != nil {
+		return false, err
+	}
+	if compare == CompareMD5 {
+		info, err := svc.HeadWithContext(ctx, obj.key)
+		if err != nil {
+			return false, err
+		}
+		if stored, ok := info.Metadata[syncMD5Metadata]; ok {
+			return stored == sum, nil
+		}
+	}
+	return obj.etag == sum, nil
+}
+
+func fileMD5(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// concurrency returns the number of transfers and comparisons run at once.
+func (opts *SyncOptions) concurrency() int {
+	if opts.Concurrency <= 0 {
+		return defaultSyncConcurrency
+	}
+	return opts.Concurrency
+}
+
+// syncTransfer runs the uploads or downloads, opts.Concurrency at a time,
+// stopping at the first error.
+func (svc *Service) syncTransfer(ctx context.Context, actions []SyncAction, opts *SyncOptions) error {
+	return syncEach(ctx, opts.concurrency(), len(actions), func(ctx context.Context, i int) error {
+		a := actions[i]
+		var err error
+		if a.Op == SyncUpload {
+			err = svc.syncUpload(ctx, a)
+		} else {
+			err = svc.syncDownload(ctx, a)
+		}
+		if err != nil {
+			return fmt.Errorf("sync %s %s: %w", a.Op, a.Key, err)
+		}
+		return nil
+	})
+}
+
+// syncEach calls fn with each index below n, concurrency at a time, stopping
+// at the first error.
+func syncEach(ctx context.Context, concurrency, n int, fn func(context.Context, int) error) error {
+	if n == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	work := make(chan int)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				if err := fn(ctx, i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case work <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+func (svc *Service) syncUpload(ctx context.Context, a SyncAction) error {
+	sum, err := fileMD5(a.Path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(a.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	in := svc.uploadInput(a.Key, f)
+	in.Metadata = map[string]*string{syncMD5Metadata: aws.String(sum)}
+	if t := mime.TypeByExtension(path.Ext(a.Key)); t != "" {
+		in.ContentType = aws.String(t)
+	}
+	_, err = s3manager.NewUploaderWithClient(svc.svc).UploadWithContext(ctx, in)
+	return svc.lockError(ctx, err, svc.name, a.Key, "")
+}
+
+// syncDownload writes the object to a temporary file beside the destination
+// and renames it into place, so a failed download leaves no partial file.
+// A new file is created with mode 0666 less the umask, as os.Create would,
+// and a replaced file keeps its mode.
+func (svc *Service) syncDownload(ctx context.Context, a SyncAction) error {
+	dir := filepath.Dir(a.Path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	res, err := svc.getObject(ctx, a.Key)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	tmp, err := syncTempFile(dir)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly once renamed
+	if _, err := io.Copy(tmp, res.Body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if info, err := os.Stat(a.Path); err == nil {
+		if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), a.Path)
+}
+
+// syncTempFile creates a new file in dir with mode 0666 less the umask,
+// unlike ioutil.TempFile, whose files are 0600.
+func syncTempFile(dir string) (*os.File, error) {
+	for {
+		var b [8]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(filepath.Join(dir, ".sync-"+hex.EncodeToString(b[:])), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if !os.IsExist(err) {
+			return f, err
+		}
+	}
+}
+
+// syncDelete removes the extraneous objects, or files when syncing down.
+func (svc *Service) syncDelete(ctx context.Context, actions []SyncAction, direction SyncDirection) error {
+	if len(actions) == 0 {
+		return nil
+	}
+	if direction == SyncDown {
+		for _, a := range actions {
+			if err := os.Remove(a.Path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	keys := make([]string, len(actions))
+	for i, a := range actions {
+		keys[i] = a.Key
+	}
+	return svc.DeleteManyWithContext(ctx, keys)
+}
+
+func sortSyncActions(actions []SyncAction) {
+	sort.Slice(actions, func(i, j int) bool { return actions[i].Key < actions[j].Key })
+}
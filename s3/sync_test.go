@@ -0,0 +1,195 @@
+package s3
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cleardataeng/aidews/s3/extmocks/github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+)
+
+func syncDir(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func expectSyncList(s3Mock *mock_s3iface.MockS3API, objs ...*s3.Object) {
+	s3Mock.EXPECT().ListObjectsV2PagesWithContext(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.ListObjectsV2Input, f func(*s3.ListObjectsV2Output, bool) bool, _ ...request.Option) error {
+			f(&s3.ListObjectsV2Output{Contents: objs}, true)
+			return nil
+		},
+	)
+}
+
+func TestService_SyncUpDryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	expectSyncList(s3Mock,
+		&s3.Object{Key: aws.String("site/a.txt"), Size: aws.Int64(3), ETag: aws.String(`"900150983cd24fb0d6963f7d28e17f72"`)},
+		&s3.Object{Key: aws.String("site/css/old.css"), Size: aws.Int64(1)},
+		&s3.Object{Key: aws.String("site/debug.log"), Size: aws.Int64(1)},
+	)
+	dir := syncDir(t, map[string]string{
+		"a.txt":        "abc",
+		"b.txt":        "changed",
+		"css/main.css": "body{}",
+		"debug.log":    "skipped",
+	})
+
+	svc := newWithSvc(testBucket, s3Mock)
+	got, err := svc.SyncWithContext(ctx, dir, "site/", &SyncOptions{
+		Compare: CompareETag,
+		Exclude: []string{"*.log"},
+		Delete:  true,
+		DryRun:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SyncAction{
+		{Op: SyncUpload, Key: "site/b.txt", Path: filepath.Join(dir, "b.txt"), Size: 7},
+		{Op: SyncUpload, Key: "site/css/main.css", Path: filepath.Join(dir, "css", "main.css"), Size: 6},
+		{Op: SyncDelete, Key: "site/css/old.css", Path: filepath.Join(dir, "css", "old.css")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("actions: wanted: %+v, got: %+v", want, got)
+	}
+}
+
+func TestService_SyncUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	expectSyncList(s3Mock,
+		&s3.Object{Key: aws.String("site/a.txt"), Size: aws.Int64(3), ETag: aws.String(`"multipart-1"`)},
+		&s3.Object{Key: aws.String("site/b.txt"), Size: aws.Int64(3), ETag: aws.String(`"multipart-1"`)},
+	)
+	// a.txt is unchanged by its stored MD5, b.txt is changed
+	s3Mock.EXPECT().HeadObjectWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.HeadObjectInput, _ ...request.Option) (*s3.HeadObjectOutput, error) {
+			sum := "900150983cd24fb0d6963f7d28e17f72" // abc
+			if aws.StringValue(in.Key) == "site/b.txt" {
+				sum = "0"
+			}
+			return &s3.HeadObjectOutput{ContentLength: aws.Int64(3), Metadata: map[string]*string{"Md5": aws.String(sum)}}, nil
+		},
+	).Times(2)
+	var mu sync.Mutex
+	uploaded := map[string]*s3.PutObjectInput{}
+	bodies := map[string]string{}
+	s3Mock.EXPECT().PutObjectRequest(gomock.Any()).DoAndReturn(
+		func(in *s3.PutObjectInput) (*request.Request, *s3.PutObjectOutput) {
+			body, err := ioutil.ReadAll(in.Body)
+			if err != nil {
+				t.Error(err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			uploaded[aws.StringValue(in.Key)] = in
+			bodies[aws.StringValue(in.Key)] = string(body)
+			out := &s3.PutObjectOutput{}
+			return request.New(aws.Config{}, metadata.ClientInfo{}, request.Handlers{}, nil, &request.Operation{Name: "PutObject"}, in, out), out
+		},
+	).Times(2)
+	dir := syncDir(t, map[string]string{
+		"a.txt":      "abc",
+		"b.txt":      "new",
+		"index.html": "<p>",
+	})
+
+	svc := newWithSvc(testBucket, s3Mock)
+	got, err := svc.SyncWithContext(ctx, dir, "site/", &SyncOptions{Compare: CompareMD5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SyncAction{
+		{Op: SyncUpload, Key: "site/b.txt", Path: filepath.Join(dir, "b.txt"), Size: 3},
+		{Op: SyncUpload, Key: "site/index.html", Path: filepath.Join(dir, "index.html"), Size: 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("actions: wanted: %+v, got: %+v", want, got)
+	}
+	if bodies["site/b.txt"] != "new" || bodies["site/index.html"] != "<p>" {
+		t.Errorf("unexpected uploads: %v", bodies)
+	}
+	in := uploaded["site/index.html"]
+	if in == nil {
+		t.Fatal("index.html not uploaded")
+	}
+	if got := aws.StringValue(in.Metadata[syncMD5Metadata]); got != "4da1a46ec20cf93ee5c846a51e04f0ed" {
+		t.Errorf(`md5: want: "4da1a46ec20cf93ee5c846a51e04f0ed", got: "%s"`, got)
+	}
+	if got := aws.StringValue(in.ContentType); got != "text/html; charset=utf-8" {
+		t.Errorf(`content type: want: "text/html; charset=utf-8", got: "%s"`, got)
+	}
+}
+
+func TestService_SyncDown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	expectSyncList(s3Mock,
+		&s3.Object{Key: aws.String("site/"), Size: aws.Int64(0)},
+		&s3.Object{Key: aws.String("site/a.txt"), Size: aws.Int64(3)},
+		&s3.Object{Key: aws.String("site/css/main.css"), Size: aws.Int64(6)},
+	)
+	s3Mock.EXPECT().GetObjectWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.GetObjectInput, _ ...request.Option) (*s3.GetObjectOutput, error) {
+			if got := aws.StringValue(in.Key); got != "site/css/main.css" {
+				t.Errorf(`key: want: "site/css/main.css", got: "%s"`, got)
+			}
+			return &s3.GetObjectOutput{Body: ioutil.NopCloser(strings.NewReader("body{}"))}, nil
+		},
+	)
+	dir := syncDir(t, map[string]string{
+		"a.txt":     "abc",
+		"stale.txt": "gone",
+	})
+
+	svc := newWithSvc(testBucket, s3Mock)
+	if _, err := svc.SyncWithContext(ctx, dir, "site/", &SyncOptions{Direction: SyncDown, Delete: true}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "css", "main.css"))
+	if err != nil || string(data) != "body{}" {
+		t.Errorf("downloaded file: want: body{}, got: %s, %v", data, err)
+	}
+	// a downloaded file has the mode os.Create gives under the umask
+	ref, err := os.Create(filepath.Join(t.TempDir(), "ref"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref.Close()
+	refInfo, _ := os.Stat(ref.Name())
+	info, err := os.Stat(filepath.Join(dir, "css", "main.css"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode() != refInfo.Mode() {
+		t.Errorf("downloaded file mode: want: %v, got: %v", refInfo.Mode(), info.Mode())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "stale.txt")); !os.IsNotExist(err) {
+		t.Errorf("extraneous file not deleted: %v", err)
+	}
+}
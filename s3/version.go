@@ -0,0 +1,147 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// ObjectVersion describes a version of an object, or a delete marker, in a
+// versioned bucket.
+type ObjectVersion struct {
+	// Key of the object.
+	Key string
+
+	// VersionID identifies the version.
+	VersionID string
+
+	// IsLatest is true for the current version of the object.
+	IsLatest bool
+
+	// IsDeleteMarker is true when the version is a delete marker rather than
+	// content. Size, ETag and StorageClass are not set on delete markers.
+	IsDeleteMarker bool
+
+	// Size of the version in bytes.
+	Size int64
+
+	// ETag of the version without the surrounding quotes.
+	ETag string
+
+	// LastModified is when the version was written.
+	LastModified time.Time
+
+	// StorageClass of the version.
+	StorageClass string
+}
+
+// ListVersions calls fn with every version and delete marker under prefix.
+// See ListVersionsWithContext.
+func (svc *Service) ListVersions(prefix string, fn func(ObjectVersion) bool) error {
+	return svc.ListVersionsWithContext(context.TODO(), prefix, fn)
+}
+
+// ListVersionsWithContext calls fn with every version and delete marker under
+// prefix. Versions are ordered by key, newest first for each key, and pages are
+// requested only as fn consumes them.
+// fn should return false if it wants to stop listing.
+func (svc *Service) ListVersionsWithContext(ctx context.Context, prefix string, fn func(ObjectVersion) bool) error {
+	in := &s3.ListObjectVersionsInput{
+		Bucket: aws.String(svc.name),
+	}
+	if prefix != "" {
+		in.SetPrefix(prefix)
+	}
+	pager := func(page *s3.ListObjectVersionsOutput, last bool) bool {
+		for _, v := range pageVersions(page) {
+			if !fn(v) {
+				return false
+			}
+		}
+		return !last
+	}
+	return svc.svc.ListObjectVersionsPagesWithContext(ctx, in, pager)
+}
+
+// ReadVersion gets the given version of the object from the bucket at the key.
+// See ReadVersionWithContext.
+func (svc *Service) ReadVersion(key, versionID string) (*io.ReadCloser, error) {
+	return svc.ReadVersionWithContext(context.TODO(), key, versionID)
+}
+
+// ReadVersionWithContext gets the given version of the object from the bucket
+// at the key.
+func (svc *Service) ReadVersionWithContext(ctx context.Context, key, versionID string) (*io.ReadCloser, error) {
+	res, err := svc.getObjectVersion(ctx, key, versionID)
+	if err != nil {
+		return nil, err
+	}
+	return &res.Body, nil
+}
+
+// RestoreVersion makes the given version of the object at the key current
+// again. See RestoreVersionWithContext.
+func (svc *Service) RestoreVersion(key, versionID string) (*s3.CopyObjectOutput, error) {
+	return svc.RestoreVersionWithContext(context.TODO(), key, versionID)
+}
+
+// RestoreVersionWithContext makes the given version of the object at the key
+// current again by copying it over the key. The restored copy is stored with
+// the Service ACL and SSE settings; the old version is left in place.
+func (svc *Service) RestoreVersionWithContext(ctx context.Context, key, versionID string) (*s3.CopyObjectOutput, error) {
+	return svc.copyVersion(ctx, key, versionID, svc.name, key)
+}
+
+// copySource returns the URL-encoded CopySource for a version of an object.
+// The current version is used when versionID is empty. Plus signs are escaped
+// too, since S3 would otherwise decode them as spaces.
+func copySource(bucket, key, versionID string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(s), "+", "%2B")
+	}
+	src := bucket + "/" + strings.Join(segments, "/")
+	if versionID != "" {
+		src += "?versionId=" + url.QueryEscape(versionID)
+	}
+	return src
+}
+
+// pageVersions merges the versions and delete markers of a page, ordered by
+// key and then newest first.
+func pageVersions(page *s3.ListObjectVersionsOutput) []ObjectVersion {
+	out := make([]ObjectVersion, 0, len(page.Versions)+len(page.DeleteMarkers))
+	for _, v := range page.Versions {
+		out = append(out, ObjectVersion{
+			Key:          aws.StringValue(v.Key),
+			VersionID:    aws.StringValue(v.VersionId),
+			IsLatest:     aws.BoolValue(v.IsLatest),
+			Size:         aws.Int64Value(v.Size),
+			ETag:         trimETag(v.ETag),
+			LastModified: aws.TimeValue(v.LastModified),
+			StorageClass: aws.StringValue(v.StorageClass),
+		})
+	}
+	for _, m := range page.DeleteMarkers {
+		out = append(out, ObjectVersion{
+			Key:            aws.StringValue(m.Key),
+			VersionID:      aws.StringValue(m.VersionId),
+			IsLatest:       aws.BoolValue(m.IsLatest),
+			IsDeleteMarker: true,
+			LastModified:   aws.TimeValue(m.LastModified),
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Key != out[j].Key {
+			return out[i].Key < out[j].Key
+		}
+		return out[i].LastModified.After(out[j].LastModified)
+	})
+	return out
+}
@@ -0,0 +1,93 @@
+package s3
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cleardataeng/aidews/s3/extmocks/github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+)
+
+func TestService_ListVersionsWithContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+
+	older, newer := modified, modified.Add(time.Hour)
+	s3Mock.EXPECT().ListObjectVersionsPagesWithContext(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.ListObjectVersionsInput, f func(*s3.ListObjectVersionsOutput, bool) bool, _ ...request.Option) error {
+			if got := aws.StringValue(in.Prefix); got != "keys/" {
+				t.Errorf(`prefix: want: "keys/", got: "%s"`, got)
+			}
+			f(&s3.ListObjectVersionsOutput{
+				Versions: []*s3.ObjectVersion{
+					{Key: aws.String("keys/a"), VersionId: aws.String("2"), IsLatest: aws.Bool(true), Size: aws.Int64(2), ETag: aws.String(`"a2"`), LastModified: &newer},
+					{Key: aws.String("keys/a"), VersionId: aws.String("1"), Size: aws.Int64(1), ETag: aws.String(`"a1"`), LastModified: &older},
+					{Key: aws.String("keys/b"), VersionId: aws.String("1"), Size: aws.Int64(1), ETag: aws.String(`"b1"`), LastModified: &older},
+				},
+				DeleteMarkers: []*s3.DeleteMarkerEntry{
+					{Key: aws.String("keys/b"), VersionId: aws.String("2"), IsLatest: aws.Bool(true), LastModified: &newer},
+				},
+			}, true)
+			return nil
+		},
+	)
+
+	var got []ObjectVersion
+	svc := newWithSvc(testBucket, s3Mock)
+	err := svc.ListVersionsWithContext(ctx, "keys/", func(v ObjectVersion) bool {
+		got = append(got, v)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ObjectVersion{
+		{Key: "keys/a", VersionID: "2", IsLatest: true, Size: 2, ETag: "a2", LastModified: newer},
+		{Key: "keys/a", VersionID: "1", Size: 1, ETag: "a1", LastModified: older},
+		{Key: "keys/b", VersionID: "2", IsLatest: true, IsDeleteMarker: true, LastModified: newer},
+		{Key: "keys/b", VersionID: "1", Size: 1, ETag: "b1", LastModified: older},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("versions: wanted: %+v, got: %+v", want, got)
+	}
+}
+
+func TestService_RestoreVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s3Mock := mock_s3iface.NewMockS3API(ctrl)
+	s3Mock.EXPECT().HeadObjectWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.HeadObjectInput, _ ...request.Option) (*s3.HeadObjectOutput, error) {
+			if got := aws.StringValue(in.VersionId); got != "v+1" {
+				t.Errorf(`version: want: "v+1", got: "%s"`, got)
+			}
+			return &s3.HeadObjectOutput{ContentLength: aws.Int64(1)}, nil
+		},
+	)
+	s3Mock.EXPECT().CopyObjectWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, in *s3.CopyObjectInput, _ ...request.Option) (*s3.CopyObjectOutput, error) {
+			wantSource := testBucket + "/my%20docs/a%2Bb.txt?versionId=v%2B1"
+			if got := aws.StringValue(in.CopySource); got != wantSource {
+				t.Errorf(`copy source: want: "%s", got: "%s"`, wantSource, got)
+			}
+			if got := aws.StringValue(in.Key); got != "my docs/a+b.txt" {
+				t.Errorf(`key: want: "my docs/a+b.txt", got: "%s"`, got)
+			}
+			if got := aws.StringValue(in.ServerSideEncryption); got != "AES256" {
+				t.Errorf(`sse: want: "AES256", got: "%s"`, got)
+			}
+			return &s3.CopyObjectOutput{}, nil
+		},
+	)
+
+	svc := newWithSvc(testBucket, s3Mock)
+	if _, err := svc.RestoreVersion("my docs/a+b.txt", "v+1"); err != nil {
+		t.Fatal(err)
+	}
+}